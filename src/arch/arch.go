@@ -5,10 +5,14 @@
 // 主要功能包括：
 // - 检测可执行文件的架构类型
 // - 验证和规范化架构字符串
+// - 比较架构字符串
+// - 读取可执行文件的版本资源
 package arch
 
 import (
+	"bytes"
 	"encoding/hex"
+	"io"
 	"os"
 	"strings"
 )
@@ -22,43 +26,66 @@ import (
 //
 // 返回值: 是否找到匹配
 func SearchBytesInFile(path string, match string, limit int) bool {
+	_, found := SearchBytesOffset(path, match, limit)
+	return found
+}
+
+// SearchBytesOffset 在文件中搜索指定的字节序列，并返回第一个匹配的位置
+// 参数:
+//
+//	path: 文件路径
+//	match: 要匹配的16进制字符串
+//	limit: 最大搜索字节数(匹配必须完整位于前limit个字节中)
+//
+// 返回值:
+//
+//	int64: 第一个匹配相对文件开头的偏移量(未找到时为-1)
+//	bool: 是否找到匹配(16进制字符串无效或文件无法读取时返回false)
+func SearchBytesOffset(path string, match string, limit int) (int64, bool) {
 	// 将16进制字符串转换为字节数组
 	toMatch, err := hex.DecodeString(match)
 	if err != nil {
-		return false
+		return -1, false
 	}
 
-	// 打开文件
-	file, err := os.Open(path)
+	header, err := readHeader(path, limit)
 	if err != nil {
-		return false
+		return -1, false
 	}
+	i := bytes.Index(header, toMatch)
+	if i < 0 {
+		return -1, false
+	}
+	return int64(i), true
+}
 
-	// 确保文件关闭
-	defer file.Close()
+// readHeader 一次性读取文件开头的指定字节数(内部函数)
+// 参数:
+//
+//	path: 文件路径
+//	limit: 最大读取字节数
+//
+// 返回值:
+//
+//	[]byte: 读取到的内容(文件较小时可能少于limit，limit小于等于0时为空)
+//	error: 打开或读取过程中遇到的错误
+func readHeader(path string, limit int) ([]byte, error) {
+	if limit <= 0 {
+		return []byte{}, nil
+	}
 
-	// 创建1字节缓冲区用于读取
-	bit := make([]byte, 1)
-	j := 0
-	for i := 0; i < limit; i++ {
-		file.Read(bit)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
-		// 匹配失败则重置匹配位置
-		if bit[0] != toMatch[j] {
-			j = 0
-		}
-		// 匹配成功则前进位置
-		if bit[0] == toMatch[j] {
-			j++
-			// 完全匹配则返回成功
-			if j >= len(toMatch) {
-				file.Close()
-				return true
-			}
-		}
+	header := make([]byte, limit)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
 	}
-	file.Close()
-	return false
+	return header[:n], nil
 }
 
 // Bit 检测可执行文件的架构类型
@@ -68,20 +95,28 @@ func SearchBytesInFile(path string, match string, limit int) bool {
 //
 // 返回值: 架构类型("arm64"/"64"/"32"/"?")
 func Bit(path string) string {
-	// 通过文件头特征检测架构类型
-	isarm64 := SearchBytesInFile(path, "5045000064AA", 400)
-	is64 := SearchBytesInFile(path, "504500006486", 400)
-	is32 := SearchBytesInFile(path, "504500004C", 400)
-	if isarm64 {
+	// 只读取一次文件头，再依次匹配PE签名及其后的机器类型
+	header, err := readHeader(path, 400)
+	if err != nil {
+		return "?"
+	}
+	if bytes.Contains(header, peArm64) {
 		return "arm64"
-	} else if is64 {
+	} else if bytes.Contains(header, peAmd64) {
 		return "64"
-	} else if is32 {
+	} else if bytes.Contains(header, peI386) {
 		return "32"
 	}
 	return "?"
 }
 
+// PE文件头特征("PE\0\0"签名及机器类型)
+var (
+	peArm64 = []byte{0x50, 0x45, 0x00, 0x00, 0x64, 0xAA}
+	peAmd64 = []byte{0x50, 0x45, 0x00, 0x00, 0x64, 0x86}
+	peI386  = []byte{0x50, 0x45, 0x00, 0x00, 0x4C}
+)
+
 // Validate 验证和规范化架构字符串
 // 参数:
 //
@@ -104,3 +139,54 @@ func Validate(str string) string {
 	// 默认为32位
 	return "32"
 }
+
+// synonyms 常见架构别名与规范化架构的对应关系
+var synonyms = map[string]string{
+	"arm64":   "arm64",
+	"aarch64": "arm64",
+	"64":      "64",
+	"x64":     "64",
+	"amd64":   "64",
+	"x86_64":  "64",
+	"32":      "32",
+	"x86":     "32",
+	"i386":    "32",
+	"i686":    "32",
+	"386":     "32",
+}
+
+// Equal 检查两个架构字符串是否表示同一架构
+// 参数:
+//
+//	a: 第一个架构字符串
+//	b: 第二个架构字符串
+//
+// 返回值: 规范化后架构相同时返回true
+func Equal(a, b string) bool {
+	return canonical(a) == canonical(b)
+}
+
+// canonical 将架构字符串转换为规范化形式(内部函数)
+// 优先匹配别名表，无法识别时按Validate规则处理
+// 参数:
+//
+//	str: 原始架构字符串
+//
+// 返回值: 规范化后的架构("arm64"/"64"/"32")
+func canonical(str string) string {
+	if value, ok := synonyms[strings.ToLower(strings.TrimSpace(str))]; ok {
+		return value
+	}
+	return Validate(strings.ToLower(str))
+}
+
+// Current 获取当前操作系统的架构
+// 32位进程运行在64位系统上时，PROCESSOR_ARCHITECTURE反映的是进程架构，
+// 因此优先读取PROCESSOR_ARCHITEW6432
+// 返回值: 规范化后的架构("arm64"/"64"/"32")
+func Current() string {
+	if str := os.Getenv("PROCESSOR_ARCHITEW6432"); str != "" {
+		return canonical(str)
+	}
+	return canonical(os.Getenv("PROCESSOR_ARCHITECTURE"))
+}
@@ -0,0 +1,87 @@
+package arch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEqualSynonyms(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"x64", "amd64", true},
+		{"64", "x86_64", true},
+		{"AMD64", "64", true},
+		{"aarch64", "arm64", true},
+		{"ARM64", "arm64", true},
+		{"x86", "i386", true},
+		{"32", "x86", true},
+		{" i686 ", "386", true},
+		{"arm64", "64", false},
+		{"x86", "x64", false},
+		{"aarch64", "amd64", false},
+	}
+	for _, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("Equal(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSearchBytesNonPositiveLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.exe")
+	if err := os.WriteFile(path, []byte("MZ\x00\x00PE\x00\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, limit := range []int{0, -1, -4096} {
+		if SearchBytesInFile(path, "5045", limit) {
+			t.Errorf("SearchBytesInFile with limit %d found a match", limit)
+		}
+		if offset, found := SearchBytesOffset(path, "5045", limit); found || offset != -1 {
+			t.Errorf("SearchBytesOffset with limit %d = (%d, %v), want (-1, false)", limit, offset, found)
+		}
+	}
+}
+
+func TestSearchBytesOffset(t *testing.T) {
+	data := make([]byte, 512)
+	copy(data[0:], "MZ")
+	copy(data[128:], []byte{0x50, 0x45, 0x00, 0x00, 0x64, 0x86})
+	copy(data[300:], []byte{0xde, 0xad, 0xbe, 0xef})
+	copy(data[400:], []byte{0xde, 0xad, 0xbe, 0xef})
+	path := filepath.Join(t.TempDir(), "node.exe")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		match  string
+		limit  int
+		offset int64
+		found  bool
+	}{
+		{"4d5a", 512, 0, true},
+		{"504500006486", 512, 128, true},
+		{"deadbeef", 512, 300, true},
+		{"504500006486", 134, 128, true},
+		{"504500006486", 133, -1, false},
+		{"cafebabe", 512, -1, false},
+		{"not-hex", 512, -1, false},
+		{"4d5a", 4096, 0, true},
+	}
+	for _, tt := range tests {
+		offset, found := SearchBytesOffset(path, tt.match, tt.limit)
+		if offset != tt.offset || found != tt.found {
+			t.Errorf("SearchBytesOffset(%q, %d) = (%d, %v), want (%d, %v)", tt.match, tt.limit, offset, found, tt.offset, tt.found)
+		}
+		if got := SearchBytesInFile(path, tt.match, tt.limit); got != tt.found {
+			t.Errorf("SearchBytesInFile(%q, %d) = %v, want %v", tt.match, tt.limit, got, tt.found)
+		}
+	}
+
+	if _, found := SearchBytesOffset(filepath.Join(t.TempDir(), "missing.exe"), "4d5a", 512); found {
+		t.Errorf("found a match in a missing file")
+	}
+}
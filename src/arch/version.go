@@ -0,0 +1,60 @@
+package arch
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// ErrNoVersionResource 可执行文件不包含版本资源(VS_VERSION_INFO)
+var ErrNoVersionResource = errors.New("no version resource found")
+
+// FileVersion 读取可执行文件版本资源(VS_VERSION_INFO)中的版本号
+// 优先返回字符串形式的ProductVersion(node.exe中为"18.16.0"这样的Node.js版本)，
+// 不存在时返回VS_FIXEDFILEINFO中的文件版本("主.次.修订.内部")
+// 参数:
+//
+//	path: 可执行文件路径
+//
+// 返回值:
+//
+//	string: 版本号
+//	error: 文件不包含版本资源时返回包装了ErrNoVersionResource的错误
+func FileVersion(path string) (string, error) {
+	size, err := windows.GetFileVersionInfoSize(path, nil)
+	if err != nil || size == 0 {
+		return "", fmt.Errorf("%w in %s: %v", ErrNoVersionResource, path, err)
+	}
+
+	info := make([]byte, size)
+	if err := windows.GetFileVersionInfo(path, 0, size, unsafe.Pointer(&info[0])); err != nil {
+		return "", fmt.Errorf("failed to read version resource of %s: %w", path, err)
+	}
+	block := unsafe.Pointer(&info[0])
+
+	// 字符串版本信息按语言和代码页存储，先读取第一个可用的翻译
+	var ptr unsafe.Pointer
+	var n uint32
+	if err := windows.VerQueryValue(block, `\VarFileInfo\Translation`, unsafe.Pointer(&ptr), &n); err == nil && n >= 4 {
+		translation := (*[2]uint16)(ptr)
+		for _, key := range []string{"ProductVersion", "FileVersion"} {
+			sub := fmt.Sprintf(`\StringFileInfo\%04x%04x\%s`, translation[0], translation[1], key)
+			if err := windows.VerQueryValue(block, sub, unsafe.Pointer(&ptr), &n); err == nil && n > 0 {
+				if value := windows.UTF16PtrToString((*uint16)(ptr)); value != "" {
+					return value, nil
+				}
+			}
+		}
+	}
+
+	// 回退到固定格式的文件版本
+	if err := windows.VerQueryValue(block, `\`, unsafe.Pointer(&ptr), &n); err != nil || n < uint32(unsafe.Sizeof(windows.VS_FIXEDFILEINFO{})) {
+		return "", fmt.Errorf("%w in %s", ErrNoVersionResource, path)
+	}
+	fixed := (*windows.VS_FIXEDFILEINFO)(ptr)
+	return fmt.Sprintf("%d.%d.%d.%d",
+		fixed.FileVersionMS>>16, fixed.FileVersionMS&0xffff,
+		fixed.FileVersionLS>>16, fixed.FileVersionLS&0xffff), nil
+}
@@ -126,3 +126,30 @@ func Bridge(args ...string) {
 		fmt.Println("bridge command finished with error:", err)
 	}
 }
+
+// Start 启动author-nvm.exe桥接程序但不等待其结束
+// 与Bridge不同，Start不会隐藏控制台窗口或退出当前进程，子进程启动后即返回
+// 参数:
+//
+//	args: 传递给桥接程序的参数
+//
+// 返回值: 桥接程序不存在或子进程启动失败时返回错误
+func Start(args ...string) error {
+	bridge := bridgePath()
+	if !fsutil.Exists(bridge) {
+		return fmt.Errorf("%w: %s", ErrBridgeNotFound, bridge)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("no command passed to author bridge")
+	}
+
+	cmd := exec.Command(bridge, args...)
+	cmd.SysProcAttr = &windows.SysProcAttr{
+		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS | windows.CREATE_NO_WINDOW,
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error starting bridge command: %w", err)
+	}
+	// 子进程已分离，释放句柄即可，无需等待
+	return cmd.Process.Release()
+}
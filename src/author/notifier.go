@@ -0,0 +1,50 @@
+package author
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"nvm/upgrade"
+
+	"github.com/coreybutler/go-fsutil"
+)
+
+// ErrBridgeNotFound author-nvm.exe桥接程序不存在
+var ErrBridgeNotFound = errors.New("author bridge not found")
+
+// BridgeNotifier 通过author-nvm.exe桥接程序发送系统通知，实现upgrade.Notifier
+type BridgeNotifier struct{}
+
+// Notify 通过桥接程序发送系统通知
+// 桥接程序以分离的子进程启动，Notify在子进程启动后即返回
+// 参数:
+//
+//	data: 通知内容
+//
+// 返回值: 桥接程序不存在时返回ErrBridgeNotFound，子进程启动失败时返回对应错误
+func (BridgeNotifier) Notify(data upgrade.Notification) error {
+	// 提前检查，以便调用方通过ErrBridgeNotFound区分桥接程序缺失
+	if !Available() {
+		return ErrBridgeNotFound
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return Start("notify", string(content))
+}
+
+// Available 检查author-nvm.exe桥接程序是否存在
+// 返回值: 桥接程序与nvm.exe位于同一目录时返回true
+func Available() bool {
+	return fsutil.Exists(bridgePath())
+}
+
+// bridgePath 获取author-nvm.exe桥接程序的路径(内部函数)
+// 返回值: 与nvm.exe位于同一目录的桥接程序路径
+func bridgePath() string {
+	exe, _ := os.Executable()
+	return filepath.Join(filepath.Dir(exe), "author-nvm.exe")
+}
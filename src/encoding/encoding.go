@@ -2,10 +2,13 @@
 // 主要功能包括：
 // - 检测字节内容的字符编码
 // - 将字符串转换为UTF-8编码的字节数组
+// - 解码Windows命令输出(UTF-8或UTF-16)
 package encoding
 
 import (
+	"encoding/binary"
 	"strings"
+	"unicode/utf16"
 	"unicode/utf8"
 
 	"github.com/saintfish/chardet"
@@ -46,6 +49,65 @@ func ToUTF8(content string) []byte {
 	return b[:i]
 }
 
+// DecodeOutput 将命令输出解码为字符串
+// 部分Windows区域设置下控制台会输出UTF-16编码的内容，此函数根据BOM或
+// 空字节分布识别UTF-16LE/UTF-16BE并转换，其他内容按UTF-8处理
+// 参数:
+//
+//	content: 命令输出的原始字节
+//
+// 返回值: 解码后的字符串
+func DecodeOutput(content []byte) string {
+	var order binary.ByteOrder
+	switch {
+	case len(content) >= 2 && content[0] == 0xFF && content[1] == 0xFE:
+		order, content = binary.LittleEndian, content[2:]
+	case len(content) >= 2 && content[0] == 0xFE && content[1] == 0xFF:
+		order, content = binary.BigEndian, content[2:]
+	case len(content) >= 2 && len(content)%2 == 0:
+		order = utf16Order(content)
+	}
+
+	if order == nil {
+		return strings.TrimPrefix(string(content), "\uFEFF")
+	}
+
+	units := make([]uint16, 0, len(content)/2)
+	for i := 0; i+1 < len(content); i += 2 {
+		units = append(units, order.Uint16(content[i:]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// utf16Order 根据空字节的位置推断无BOM的UTF-16字节序(内部函数)
+// ASCII字符编码为UTF-16后高位字节为0，据此判断字节序
+// 参数:
+//
+//	content: 偶数长度的字节内容
+//
+// 返回值: 推断的字节序，不像UTF-16时返回nil
+func utf16Order(content []byte) binary.ByteOrder {
+	even, odd := 0, 0
+	for i := 0; i+1 < len(content); i += 2 {
+		if content[i] == 0 {
+			even++
+		}
+		if content[i+1] == 0 {
+			odd++
+		}
+	}
+
+	// 超过一半的字符含空字节时才视为UTF-16
+	half := len(content) / 4
+	if odd > half && even == 0 {
+		return binary.LittleEndian
+	}
+	if even > half && odd == 0 {
+		return binary.BigEndian
+	}
+	return nil
+}
+
 // func ToUTF8(content []byte, ignoreInvalidITF8Chars ...bool) (string, error) {
 // 	ignore := false
 // 	if len(ignoreInvalidITF8Chars) > 0 {
@@ -0,0 +1,41 @@
+package encoding
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+func encodeUTF16(s string, order binary.ByteOrder, bom bool) []byte {
+	out := []byte{}
+	if bom {
+		out = append(out, 0, 0)
+		order.PutUint16(out, 0xFEFF)
+	}
+	for _, unit := range utf16.Encode([]rune(s)) {
+		b := make([]byte, 2)
+		order.PutUint16(b, unit)
+		out = append(out, b...)
+	}
+	return out
+}
+
+func TestDecodeOutput(t *testing.T) {
+	const version = "v18.16.0\r\n"
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{"utf-8", []byte(version)},
+		{"utf-8 with bom", append([]byte{0xEF, 0xBB, 0xBF}, version...)},
+		{"utf-16le", encodeUTF16(version, binary.LittleEndian, false)},
+		{"utf-16le with bom", encodeUTF16(version, binary.LittleEndian, true)},
+		{"utf-16be", encodeUTF16(version, binary.BigEndian, false)},
+		{"utf-16be with bom", encodeUTF16(version, binary.BigEndian, true)},
+	}
+	for _, tt := range tests {
+		if got := DecodeOutput(tt.in); got != version {
+			t.Errorf("%s: DecodeOutput() = %q, want %q", tt.name, got, version)
+		}
+	}
+}
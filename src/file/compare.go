@@ -0,0 +1,127 @@
+package file
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Checksum 以流式方式计算文件校验和，不会将整个文件读入内存
+// 参数:
+//
+//	path: 文件路径
+//	hasher: 哈希算法(如sha256.New())
+//
+// 返回值:
+//
+//	string: 十六进制校验和
+//	error: 读取文件过程中遇到的错误
+func Checksum(path string, hasher hash.Hash) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
+
+// DiffDirs 比较两个目录树中的文件
+// 先比较文件大小，大小相同时再比较SHA-256校验和；逐个文件流式计算，不会缓存文件内容
+// 参数:
+//
+//	a: 第一个目录
+//	b: 第二个目录
+//
+// 返回值:
+//
+//	[]string: 内容不同或只存在于其中一个目录的文件(相对路径)
+//	error: 遍历目录或读取文件过程中遇到的错误
+func DiffDirs(a, b string) ([]string, error) {
+	diffs := []string{}
+
+	// a中的文件: 在b中缺失或内容不同
+	err := filepath.WalkDir(a, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(a, path)
+		if err != nil {
+			return err
+		}
+		same, err := sameFile(path, filepath.Join(b, rel))
+		if err != nil {
+			return err
+		}
+		if !same {
+			diffs = append(diffs, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return diffs, fmt.Errorf("error comparing %s and %s: %w", a, b, err)
+	}
+
+	// 只存在于b中的文件
+	err = filepath.WalkDir(b, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(b, path)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(a, rel)); os.IsNotExist(err) {
+			diffs = append(diffs, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return diffs, fmt.Errorf("error comparing %s and %s: %w", a, b, err)
+	}
+
+	return diffs, nil
+}
+
+// sameFile 检查两个文件的大小和内容是否相同(内部函数)
+// 参数:
+//
+//	a: 第一个文件
+//	b: 第二个文件(不存在时视为不同)
+//
+// 返回值:
+//
+//	bool: 内容相同时返回true
+//	error: 读取文件过程中遇到的错误
+func sameFile(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if infoB.IsDir() || infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+
+	sumA, err := Checksum(a, sha256.New())
+	if err != nil {
+		return false, err
+	}
+	sumB, err := Checksum(b, sha256.New())
+	if err != nil {
+		return false, err
+	}
+	return sumA == sumB, nil
+}
@@ -1,41 +1,77 @@
 // Package file 提供文件操作相关功能
 // 主要功能包括：
 // - 解压zip文件
-// - 按行读取文件内容
+// - 按行读取文件内容(ReadLines一次读取全部，EachLine流式读取)
+// - 比较目录树
 // - 检查文件是否存在
 package file
 
 import (
 	"archive/zip"
 	"bufio"
+	"errors"
+	"fmt"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
+var (
+	// ErrNotFound 文件或目录不存在(与os.ErrNotExist相同，可直接用errors.Is判断)
+	ErrNotFound = os.ErrNotExist
+	// ErrPermission 没有访问文件或目录的权限(与os.ErrPermission相同)
+	ErrPermission = os.ErrPermission
+	// ErrUnsafePath 压缩包条目的路径会解压到目标目录之外(zip-slip)，该条目已被跳过
+	ErrUnsafePath = errors.New("unsafe path in archive")
+	// ErrCorruptArchive 压缩包被截断或已损坏
+	ErrCorruptArchive = errors.New("corrupt archive")
+)
+
+// UnsafeEntriesError 解压时因路径不安全而被跳过的条目
+// 其余条目仍会正常解压，errors.Is(err, ErrUnsafePath)返回true
+type UnsafeEntriesError struct {
+	Archive string   // 压缩包路径
+	Entries []string // 被跳过的条目名称
+}
+
+// Error 实现error接口
+func (e *UnsafeEntriesError) Error() string {
+	return fmt.Sprintf("%v: skipped %d entries in %s: %s", ErrUnsafePath, len(e.Entries), e.Archive, strings.Join(e.Entries, ", "))
+}
+
+// Unwrap 返回ErrUnsafePath，以支持errors.Is
+func (e *UnsafeEntriesError) Unwrap() error {
+	return ErrUnsafePath
+}
+
 // Unzip 解压zip文件到指定目录
 // 参数:
 //
 //	src: zip文件路径
 //	dest: 解压目标目录
 //
-// 返回值: 解压过程中遇到的错误
-// 注意: 防止目录遍历攻击，拒绝包含".."的路径
-// Unzip 解压zip文件到目标目录
+// 返回值: 解压过程中遇到的错误(包装ErrCorruptArchive、ErrNotFound等)，
+// 存在会解压到dest之外的条目时跳过这些条目并在最后返回*UnsafeEntriesError
+// 注意: 防止目录遍历攻击(zip-slip)，拒绝解压到目标目录之外的路径
 func Unzip(src, dest string) error {
+	// 解压前检查压缩包完整性，避免截断的下载产生不完整的解压结果
+	if err := VerifyZip(src); err != nil {
+		return err
+	}
+
 	// 打开zip文件
 	r, err := zip.OpenReader(src)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to open %s: %w", src, err)
 	}
 	defer r.Close()
 
 	// 遍历zip中的文件
+	var unsafe []string
 	for _, f := range r.File {
 		// 安全检查：防止路径穿越攻击
-		if !strings.Contains(f.Name, "..") {
+		if safePath(dest, f.Name) {
 			// 打开zip中的文件
 			rc, err := f.Open()
 			if err != nil {
@@ -58,7 +94,6 @@ func Unzip(src, dest string) error {
 				// 创建父目录
 				err = os.MkdirAll(fdir, f.Mode())
 				if err != nil {
-					log.Fatal(err)
 					return err
 				}
 				// 创建目标文件
@@ -76,7 +111,60 @@ func Unzip(src, dest string) error {
 			}
 		} else {
 			// 记录无效文件
-			log.Printf("failed to extract file: %s (cannot validate)\n", f.Name)
+			unsafe = append(unsafe, f.Name)
+		}
+	}
+
+	if len(unsafe) > 0 {
+		return &UnsafeEntriesError{Archive: src, Entries: unsafe}
+	}
+	return nil
+}
+
+// safePath 检查压缩包条目解压后是否仍位于目标目录中(内部函数)
+// 参数:
+//
+//	dest: 解压目标目录
+//	name: 压缩包条目名称
+//
+// 返回值: 条目位于目标目录中时返回true
+func safePath(dest, name string) bool {
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return false
+	}
+	rel, err := filepath.Rel(dest, filepath.Join(dest, name))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// VerifyZip 检查zip文件是否完整(不解压)
+// 打开中央目录并读取每个条目以校验CRC，截断或损坏的压缩包会返回错误
+// 参数:
+//
+//	path: zip文件路径
+//
+// 返回值: 压缩包损坏时返回包装ErrCorruptArchive的错误
+func VerifyZip(path string) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("%w %s: %v", ErrCorruptArchive, path, err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("%w %s: %s: %v", ErrCorruptArchive, path, f.Name, err)
+		}
+		_, err = io.Copy(io.Discard, rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("%w %s: %s: %v", ErrCorruptArchive, path, f.Name, err)
 		}
 	}
 
@@ -91,16 +179,12 @@ func Unzip(src, dest string) error {
 // 返回值:
 //
 //	[]string: 文件各行内容
-//	error: 读取过程中遇到的错误
-//
-// ReadLines 读取指定文件的所有行
-// path: 文件路径
-// 返回: 字符串切片(每行内容)和可能的错误
+//	error: 读取过程中遇到的错误(可用errors.Is判断ErrNotFound、ErrPermission)
 func ReadLines(path string) ([]string, error) {
 	// 打开文件
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
 	}
 	// 确保文件关闭
 	defer file.Close()
@@ -114,7 +198,37 @@ func ReadLines(path string) ([]string, error) {
 		lines = append(lines, scanner.Text())
 	}
 	// 返回行内容和可能的扫描错误
-	return lines, scanner.Err()
+	if err := scanner.Err(); err != nil {
+		return lines, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return lines, nil
+}
+
+// EachLine 逐行读取文件内容并交给fn处理，不会把所有行保存在内存中
+// 适合扫描较大的SHASUMS或日志文件；读取结束、出错或fn返回错误时都会关闭文件
+// 参数:
+//
+//	path: 文件路径
+//	fn: 处理每一行的函数，返回错误时停止读取
+//
+// 返回值: fn返回的错误(原样返回，便于调用方用自己的哨兵错误提前结束)，或读取过程中遇到的错误
+func EachLine(path string, fn func(string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if err := fn(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
 }
 
 // Exists 检查文件是否存在
@@ -127,3 +241,17 @@ func Exists(filename string) bool {
 	_, err := os.Stat(filename)
 	return err == nil
 }
+
+// Check 检查文件是否存在，并在不存在时说明原因
+// 与Exists不同，可以区分文件不存在和没有访问权限等情况
+// 参数:
+//
+//	filename: 文件路径
+//
+// 返回值: 文件存在时返回nil，否则返回包装ErrNotFound、ErrPermission等的错误
+func Check(filename string) error {
+	if _, err := os.Stat(filename); err != nil {
+		return fmt.Errorf("cannot access %s: %w", filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeZip(t *testing.T, path string, entries map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range entries {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestVerifyZip(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "assets.zip")
+	data := writeZip(t, archive, map[string]string{"nvm.exe": string(bytes.Repeat([]byte("nvm"), 1024))})
+
+	if err := VerifyZip(archive); err != nil {
+		t.Fatalf("VerifyZip() on an intact archive = %v", err)
+	}
+
+	truncated := filepath.Join(dir, "truncated.zip")
+	if err := os.WriteFile(truncated, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyZip(truncated); !errors.Is(err, ErrCorruptArchive) {
+		t.Errorf("VerifyZip() on a truncated archive = %v, want ErrCorruptArchive", err)
+	}
+	if err := Unzip(truncated, filepath.Join(dir, "out")); !errors.Is(err, ErrCorruptArchive) {
+		t.Errorf("Unzip() on a truncated archive = %v, want ErrCorruptArchive", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "nvm.exe")); err == nil {
+		t.Error("Unzip() extracted entries from a truncated archive")
+	}
+}
+
+func TestUnzipSkipsUnsafeEntries(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "assets.zip")
+	writeZip(t, archive, map[string]string{
+		"nvm.exe":       "binary",
+		"../escape.txt": "outside",
+	})
+
+	dest := filepath.Join(dir, "out")
+	err := Unzip(archive, dest)
+	var unsafe *UnsafeEntriesError
+	if !errors.As(err, &unsafe) || !errors.Is(err, ErrUnsafePath) {
+		t.Fatalf("Unzip() = %v, want *UnsafeEntriesError", err)
+	}
+	if len(unsafe.Entries) != 1 || unsafe.Entries[0] != "../escape.txt" {
+		t.Errorf("UnsafeEntriesError.Entries = %v, want [../escape.txt]", unsafe.Entries)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "nvm.exe")); err != nil {
+		t.Errorf("safe entry was not extracted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.txt")); err == nil {
+		t.Error("unsafe entry was extracted outside the destination")
+	}
+}
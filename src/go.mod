@@ -7,7 +7,6 @@ require (
 	github.com/coreybutler/go-fsutil v1.2.0
 	github.com/coreybutler/go-where v1.0.2
 	github.com/dustin/go-humanize v1.0.1
-	github.com/go-toast/toast v0.0.0-20190211030409-01e6764cf0a4
 	github.com/ncruces/zenity v0.10.14
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/saintfish/chardet v0.0.0-20230101081208-5e3ef4b5456d
@@ -19,7 +18,6 @@ require (
 	github.com/dchest/jsmin v0.0.0-20220218165748-59f39799265f // indirect
 	github.com/josephspurrier/goversioninfo v1.4.1 // indirect
 	github.com/mattn/go-runewidth v0.0.9 // indirect
-	github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d // indirect
 	github.com/randall77/makefat v0.0.0-20210315173500-7ddd0e42c844 // indirect
 	golang.org/x/image v0.20.0 // indirect
 )
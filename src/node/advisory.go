@@ -0,0 +1,48 @@
+package node
+
+import (
+	localsemver "nvm/semver"
+	"strings"
+)
+
+// FlagVulnerable 找出受安全公告影响的已安装版本
+// 公告的键可以是具体版本(如"18.16.0")或版本范围(如">=18.0.0 <18.17.1"、"16.x")，
+// 值为对应的公告内容，与升级功能使用的alerts数据结构一致
+// 参数:
+//
+//	installed: 已安装的版本列表(如GetInstalled的结果，可带"v"前缀)
+//	advisories: 版本或版本范围到公告内容的映射
+//
+// 返回值: 至少匹配一条公告的已安装版本(保持installed中的顺序)
+func FlagVulnerable(installed []string, advisories map[string][]string) []string {
+	ranges := make([]localsemver.Range, 0, len(advisories))
+	exact := map[string]bool{}
+	for key := range advisories {
+		if r, err := localsemver.ParseRange(key); err == nil {
+			ranges = append(ranges, r)
+		} else {
+			// 无法解析为范围的键按版本字符串精确匹配
+			exact[strings.TrimPrefix(strings.TrimSpace(key), "v")] = true
+		}
+	}
+
+	flagged := []string{}
+	for _, version := range installed {
+		if exact[strings.TrimPrefix(version, "v")] {
+			flagged = append(flagged, version)
+			continue
+		}
+
+		v, err := localsemver.Parse(version)
+		if err != nil {
+			continue
+		}
+		for _, r := range ranges {
+			if r.Contains(v) {
+				flagged = append(flagged, version)
+				break
+			}
+		}
+	}
+	return flagged
+}
@@ -0,0 +1,145 @@
+package node
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"nvm/arch"
+	"nvm/file"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrArchiveMismatch 压缩包内容与指定的版本或架构不一致
+var ErrArchiveMismatch = errors.New("archive does not match the requested version")
+
+// archiveDirPattern 官方压缩包顶层目录名(如"node-v18.16.0-win-x64")
+var archiveDirPattern = regexp.MustCompile(`^node-v(.+)-win-(x64|x86|arm64)$`)
+
+// archiveArches 压缩包目录名中的架构与规范化架构的对应关系
+var archiveArches = map[string]string{"x64": "64", "x86": "32", "arm64": "arm64"}
+
+// InstallFromArchive 从本地的Node.js压缩包安装指定版本(用于无法联网的机器)
+// 压缩包解压到root下的临时目录，校验版本和架构后移动到v<version>目录，最后由ValidateInstall检查完整性
+// 参数:
+//
+//	root: NVM安装根目录
+//	version: 版本号(如"18.16.0"或"v18.16.0")
+//	cpu: 架构("32"/"64"/"arm64")
+//	archivePath: 压缩包路径(如node-v18.16.0-win-x64.zip)
+//	checksum: 可选的压缩包SHA-256校验和(十六进制，与SHASUMS256.txt一致)
+//
+// 返回值: 校验、解压或安装过程中遇到的错误，内容不一致时包装ErrArchiveMismatch
+func InstallFromArchive(root, version, cpu, archivePath string, checksum ...string) error {
+	version = strings.TrimPrefix(version, "v")
+	cpu = arch.Validate(cpu)
+	dest := filepath.Join(root, "v"+version)
+
+	if file.Exists(dest) {
+		return fmt.Errorf("version %s is already installed at %s", version, dest)
+	}
+
+	if len(checksum) > 0 && checksum[0] != "" {
+		if err := verifyArchiveChecksum(archivePath, checksum[0]); err != nil {
+			return err
+		}
+	}
+
+	// 解压到root下的临时目录，保证与目标目录位于同一磁盘以便重命名
+	tmp, err := os.MkdirTemp(root, ".nvm-archive-*")
+	if err != nil {
+		return fmt.Errorf("cannot create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := file.Unzip(archivePath, tmp); err != nil {
+		return fmt.Errorf("cannot extract %s: %w", archivePath, err)
+	}
+
+	src, err := archiveRoot(tmp, version, cpu)
+	if err != nil {
+		return err
+	}
+
+	exe := filepath.Join(src, "node.exe")
+	if bit := arch.Bit(exe); bit != cpu {
+		return fmt.Errorf("%w: node.exe in %s is %s-bit, expected %s-bit", ErrArchiveMismatch, archivePath, bit, cpu)
+	}
+
+	if err := os.Rename(src, dest); err != nil {
+		return fmt.Errorf("cannot install %s: %v", dest, err)
+	}
+
+	if err := ValidateInstall(root, version, cpu); err != nil {
+		os.RemoveAll(dest)
+		return err
+	}
+	return nil
+}
+
+// archiveRoot 查找解压后包含node.exe的目录，并按官方目录名校验版本和架构(内部函数)
+// 参数:
+//
+//	dir: 解压目录
+//	version: 期望的版本号
+//	cpu: 期望的架构
+//
+// 返回值:
+//
+//	string: 包含node.exe的目录
+//	error: 找不到node.exe或目录名与版本/架构不一致时返回的错误
+func archiveRoot(dir, version, cpu string) (string, error) {
+	if file.Exists(filepath.Join(dir, "node.exe")) {
+		return dir, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) != 1 || !entries[0].IsDir() || !file.Exists(filepath.Join(dir, entries[0].Name(), "node.exe")) {
+		return "", fmt.Errorf("%w: no node.exe found in the archive", ErrArchiveMismatch)
+	}
+
+	name := entries[0].Name()
+	if m := archiveDirPattern.FindStringSubmatch(name); m != nil {
+		if m[1] != version {
+			return "", fmt.Errorf("%w: archive contains v%s, expected v%s", ErrArchiveMismatch, m[1], version)
+		}
+		if archiveArches[m[2]] != cpu {
+			return "", fmt.Errorf("%w: archive is built for %s, expected %s-bit", ErrArchiveMismatch, m[2], cpu)
+		}
+	}
+
+	return filepath.Join(dir, name), nil
+}
+
+// verifyArchiveChecksum 校验压缩包的SHA-256校验和(内部函数)
+// 参数:
+//
+//	path: 压缩包路径
+//	expected: 期望的校验和(十六进制，不区分大小写)
+//
+// 返回值: 校验和不一致或读取失败时返回的错误
+func verifyArchiveChecksum(path, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	computed := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(computed, strings.TrimSpace(expected)) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, computed %s", path, expected, computed)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// MANIFEST_FORMAT_VERSION 清单格式版本，格式变更时递增
+const MANIFEST_FORMAT_VERSION = 1
+
+// Manifest 表示可移植的nvm安装清单(用于迁移到新机器)
+type Manifest struct {
+	FormatVersion int                `json:"formatVersion"` // 清单格式版本
+	Active        string             `json:"active"`        // 当前使用的版本(如"v18.16.0")，无则为空
+	ActiveArch    string             `json:"activeArch"`    // 当前使用的架构
+	Installed     []InstalledVersion `json:"installed"`     // 已安装的版本及架构
+}
+
+// ExportManifest 导出已安装版本和当前使用版本的清单
+// 参数:
+//
+//	root: NVM安装根目录
+//
+// 返回值:
+//
+//	[]byte: JSON格式的清单
+//	error: 序列化过程中遇到的错误
+func ExportManifest(root string) ([]byte, error) {
+	manifest := Manifest{
+		FormatVersion: MANIFEST_FORMAT_VERSION,
+		Installed:     GetInstalledDetailed(root),
+	}
+
+	if version, bit := GetCurrentVersion(); version != "Unknown" {
+		manifest.Active = "v" + version
+		manifest.ActiveArch = bit
+	}
+
+	return json.MarshalIndent(manifest, "", "  ")
+}
+
+// ImportManifest 解析清单并列出本机尚未安装的版本
+// 参数:
+//
+//	root: NVM安装根目录
+//	data: ExportManifest生成的JSON清单
+//
+// 返回值:
+//
+//	[]InstalledVersion: 需要安装的版本及架构
+//	error: 清单格式无效或版本不受支持时返回的错误
+func ImportManifest(root string, data []byte) ([]InstalledVersion, error) {
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	if manifest.FormatVersion < 1 {
+		return nil, errors.New("invalid manifest: missing format version")
+	}
+	if manifest.FormatVersion > MANIFEST_FORMAT_VERSION {
+		return nil, fmt.Errorf("unsupported manifest format version %d (maximum supported is %d)", manifest.FormatVersion, MANIFEST_FORMAT_VERSION)
+	}
+
+	// 记录本机已安装的版本及架构
+	local := map[string]map[string]bool{}
+	for _, installed := range GetInstalledDetailed(root) {
+		local[installed.Version] = map[string]bool{}
+		for _, a := range installed.Arches {
+			local[installed.Version][a] = true
+		}
+	}
+
+	missing := []InstalledVersion{}
+	for _, entry := range manifest.Installed {
+		arches := []string{}
+		for _, a := range entry.Arches {
+			if !local[entry.Version][a] {
+				arches = append(arches, a)
+			}
+		}
+		// 未记录架构的版本仅在本机完全未安装时需要安装
+		_, exists := local[entry.Version]
+		if len(arches) > 0 || (len(entry.Arches) == 0 && !exists) {
+			missing = append(missing, InstalledVersion{Version: entry.Version, Arches: arches})
+		}
+	}
+
+	return missing, nil
+}
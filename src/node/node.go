@@ -7,21 +7,80 @@
 package node
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"nvm/arch"
+	"nvm/encoding"
 	"nvm/file"
+	localsemver "nvm/semver"
+	"nvm/utility"
 	"nvm/web"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 
 	// "../semver"
 	"github.com/blang/semver"
 )
 
+// Fetcher 获取远程文本文件的函数(可替换，便于测试)
+var Fetcher func(url string) (string, error) = web.GetRemoteTextFile
+
+// ContextFetcher 支持上下文的远程文本文件获取函数，GetAvailableContext使用此函数
+var ContextFetcher func(ctx context.Context, url string) (string, error) = web.GetRemoteTextFileContext
+
+// SetFetcher 设置获取远程文本文件的函数
+// 同时替换ContextFetcher，替换后的函数无法中断，仅在调用前检查上下文是否已取消
+// 参数:
+//
+//	f: 获取函数，为nil时恢复默认的web.GetRemoteTextFile
+func SetFetcher(f func(url string) (string, error)) {
+	if f == nil {
+		Fetcher = web.GetRemoteTextFile
+		ContextFetcher = web.GetRemoteTextFileContext
+		return
+	}
+	Fetcher = f
+	ContextFetcher = func(ctx context.Context, url string) (string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		return f(url)
+	}
+}
+
+// offline 离线模式开关
+var offline = false
+
+// ErrOffline 离线模式下尝试访问网络时返回的错误
+var ErrOffline = errors.New("offline mode is enabled: remote version information is unavailable")
+
+// SetOffline 启用或禁用离线模式
+// 离线模式下不会发起任何网络请求，以下函数受此影响:
+//   - GetAvailableVersions: 返回ErrOffline
+//   - GetAvailable: 打印ErrOffline并退出
+//   - IsVersionAvailable: 返回ErrOffline
+//
+// GetInstalled等仅读取本地状态的函数不受影响
+// 参数:
+//
+//	enabled: 是否启用离线模式
+func SetOffline(enabled bool) {
+	offline = enabled
+}
+
+// IsOffline 返回是否已启用离线模式
+func IsOffline() bool {
+	return offline
+}
+
 // GetCurrentVersion 获取当前使用的Node.js版本和架构信息
 // 返回值:
 //
@@ -32,13 +91,13 @@ func GetCurrentVersion() (string, string) {
 	cmd := exec.Command("node", "-v")
 	str, err := cmd.Output()
 	if err == nil {
-		// 清理版本号字符串，去除"v"前缀和后续描述
-		v := strings.Trim(regexp.MustCompile("-.*$").ReplaceAllString(regexp.MustCompile("v").ReplaceAllString(strings.Trim(string(str), " \n\r"), ""), ""), " \n\r")
+		// 清理版本号字符串，去除"v"前缀和后续描述(先按控制台编码解码输出)
+		v := strings.Trim(regexp.MustCompile("-.*$").ReplaceAllString(regexp.MustCompile("v").ReplaceAllString(strings.Trim(encoding.DecodeOutput(str), " \n\r"), ""), ""), " \n\r")
 
 		// 获取Node.js可执行文件路径
 		cmd := exec.Command("node", "-p", "console.log(process.execPath)")
 		str, _ := cmd.Output()
-		file := strings.Trim(regexp.MustCompile("undefined").ReplaceAllString(string(str), ""), " \n\r")
+		file := strings.Trim(regexp.MustCompile("undefined").ReplaceAllString(encoding.DecodeOutput(str), ""), " \n\r")
 
 		// 通过文件路径获取架构信息
 		bit := arch.Bit(file)
@@ -47,9 +106,10 @@ func GetCurrentVersion() (string, string) {
 			cmd := exec.Command("node", "-e", "console.log(process.arch)")
 			str, err := cmd.Output()
 			if err == nil {
-				if string(str) == "x64" {
+				procArch := strings.TrimSpace(encoding.DecodeOutput(str))
+				if procArch == "x64" {
 					bit = "64"
-				} else if string(str) == "arm64" {
+				} else if procArch == "arm64" {
 					bit = "arm64"
 				} else {
 					bit = "32"
@@ -63,6 +123,73 @@ func GetCurrentVersion() (string, string) {
 	return "Unknown", ""
 }
 
+// ErrNoActiveVersion 没有正在使用的Node.js版本
+var ErrNoActiveVersion = errors.New("no active version")
+
+// ActiveVersion 通过nvm符号链接获取当前使用的Node.js版本和架构
+// 与GetCurrentVersion不同，该函数不依赖PATH，也不会启动子进程
+// 参数:
+//
+//	root: NVM安装根目录
+//	symlinkPath: nvm符号链接路径(NVM_SYMLINK)
+//
+// 返回值:
+//
+//	string: 版本号(如"18.16.0")
+//	string: 架构("32"/"64"/"arm64")
+//	error: 符号链接不存在或未指向有效的版本目录时返回ErrNoActiveVersion
+func ActiveVersion(root, symlinkPath string) (string, string, error) {
+	target, err := filepath.EvalSymlinks(symlinkPath)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrNoActiveVersion, err)
+	}
+
+	// 符号链接必须指向root下的版本目录
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		resolvedRoot = root
+	}
+	if !strings.EqualFold(filepath.Dir(target), filepath.Clean(resolvedRoot)) {
+		return "", "", fmt.Errorf("%w: %s does not point to a version in %s", ErrNoActiveVersion, symlinkPath, root)
+	}
+
+	version := strings.TrimPrefix(filepath.Base(target), "v")
+	if _, err := semver.Make(version); err != nil {
+		return "", "", fmt.Errorf("%w: %s is not a version directory", ErrNoActiveVersion, target)
+	}
+
+	bit := arch.Bit(filepath.Join(target, "node.exe"))
+	if bit == "?" {
+		return "", "", fmt.Errorf("%w: no valid node.exe in %s", ErrNoActiveVersion, target)
+	}
+
+	return version, bit, nil
+}
+
+// ActiveArch 获取指定版本目录中当前启用的架构
+// 同时安装了node32.exe和node64.exe时，通过检测node.exe判断当前使用的是哪一个
+// 参数:
+//
+//	root: NVM安装根目录
+//	version: 版本号(可带"v"前缀)
+//
+// 返回值:
+//
+//	string: 当前启用的架构("32"/"64"/"arm64")
+//	error: 版本目录中没有可识别的node.exe时返回的错误
+func ActiveArch(root, version string) (string, error) {
+	exe := filepath.Join(root, "v"+strings.TrimPrefix(version, "v"), "node.exe")
+	if !file.Exists(exe) {
+		return "", fmt.Errorf("no active node.exe found for version %s", version)
+	}
+
+	bit := arch.Bit(exe)
+	if bit == "?" {
+		return "", fmt.Errorf("cannot determine the architecture of %s", exe)
+	}
+	return bit, nil
+}
+
 // IsVersionInstalled 检查指定版本的Node.js是否已安装
 // 参数:
 //
@@ -101,17 +228,24 @@ func IsVersionInstalled(root string, version string, cpu string) bool {
 //
 //	v: 要检查的版本号
 //
-// 返回值: 是否可用
-func IsVersionAvailable(v string) bool {
+// 返回值:
+//
+//	bool: 是否可用
+//	error: 离线模式下返回ErrOffline(此时无法判断版本是否可用)
+func IsVersionAvailable(v string) (bool, error) {
+	if offline {
+		return false, ErrOffline
+	}
+
 	// Check the service to make sure the version is available
 	avail, _, _, _, _, _ := GetAvailable()
 
 	for _, b := range avail {
 		if b == v {
-			return true
+			return true, nil
 		}
 	}
-	return false
+	return false, nil
 }
 
 func reverseStringArray(str []string) []string {
@@ -132,8 +266,19 @@ func reverseStringArray(str []string) []string {
 // GetInstalled 获取指定目录下安装的所有Node.js版本
 // 返回格式为 ["v1.2.3", "v4.5.6"] 的字符串数组
 func GetInstalled(root string) []string {
+	return GetInstalledFiltered(root, true)
+}
+
+// GetInstalledFiltered 获取已安装的Node.js版本列表，可选择是否包含预发布版本
+// 参数:
+//
+//	root: NVM安装根目录
+//	includePrerelease: 是否包含预发布版本(如"v20.0.0-rc.1")
+//
+// 返回值: 已安装版本列表(按版本号降序排列，格式如["v12.18.3", "v10.22.0"])
+func GetInstalledFiltered(root string, includePrerelease bool) []string {
 	// 初始化版本列表
-	list := make([]semver.Version, 0)
+	list := make([]*localsemver.Version, 0)
 	// 读取目录下所有文件
 	files, _ := ioutil.ReadDir(root)
 
@@ -145,17 +290,25 @@ func GetInstalled(root string) []string {
 			isnode, _ := regexp.MatchString("v", files[i].Name())
 
 			if isnode {
-				// 移除"v"前缀并解析为语义化版本
+				// 移除"v"前缀并解析为语义化版本，跳过无法识别的目录
 				currentVersionString := strings.Replace(files[i].Name(), "v", "", 1)
-				currentVersion, _ := semver.Make(currentVersionString)
+				currentVersion, err := localsemver.Parse(currentVersionString)
+				if err != nil {
+					continue
+				}
+
+				// 按需跳过预发布版本
+				if !includePrerelease && len(currentVersion.Pre) > 0 {
+					continue
+				}
 
 				list = append(list, currentVersion)
 			}
 		}
 	}
 
-	// 对版本进行排序
-	semver.Sort(list)
+	// 对版本进行排序(预发布版本排在对应的正式版本之前)
+	localsemver.Sort(list)
 
 	// 准备可输出的版本字符串列表
 	loggableList := make([]string, 0)
@@ -170,7 +323,163 @@ func GetInstalled(root string) []string {
 	return loggableList
 }
 
-// BySemanticVersion 用于按语义化版本排序的字符串切片类型
+// NormalizeInstalled 将已安装版本的目录名规范化为"v<semver>"形式(如"18.16.0"重命名为"v18.16.0")
+// 符号链接(如当前启用版本的链接)以及无法识别为版本号的目录不会被处理，
+// 规范名称已被其他目录占用时同样跳过(仅大小写不同的目录如"V18.16.0"会被重命名)
+// 参数:
+//
+//	root: NVM安装根目录
+//
+// 返回值:
+//
+//	[]string: 已重命名的目录(原名称)
+//	error: 读取根目录或重命名失败时返回的错误
+func NormalizeInstalled(root string) ([]string, error) {
+	files, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read installed versions: %w", err)
+	}
+
+	// 文件系统不区分大小写，按小写名称记录已占用的目录；
+	// 不能用file.Exists判断，否则"V18.16.0"会被误认为已是规范名称
+	taken := map[string]bool{}
+	for _, f := range files {
+		taken[strings.ToLower(f.Name())] = true
+	}
+
+	renamed := []string{}
+	for _, f := range files {
+		if !f.IsDir() || f.Mode()&os.ModeSymlink == os.ModeSymlink {
+			continue
+		}
+
+		name := f.Name()
+		trimmed := name
+		if strings.HasPrefix(strings.ToLower(trimmed), "v") {
+			trimmed = trimmed[1:]
+		}
+		version, err := semver.Make(trimmed)
+		if err != nil {
+			continue
+		}
+
+		canonical := "v" + version.String()
+		if name == canonical {
+			continue
+		}
+		// 仅大小写不同时目标就是自身，可以直接重命名
+		if !strings.EqualFold(name, canonical) && taken[strings.ToLower(canonical)] {
+			continue
+		}
+
+		if err := utility.Rename(filepath.Join(root, name), filepath.Join(root, canonical)); err != nil {
+			return renamed, fmt.Errorf("cannot rename %s to %s: %w", name, canonical, err)
+		}
+		delete(taken, strings.ToLower(name))
+		taken[strings.ToLower(canonical)] = true
+		renamed = append(renamed, name)
+	}
+	return renamed, nil
+}
+
+// InstalledVersion 表示一个已安装的Node.js版本及其可用架构
+type InstalledVersion struct {
+	Version string   `json:"version"` // 版本号(如"v18.16.0")
+	Arches  []string `json:"arches"`  // 已安装的架构("32"/"64"/"arm64")
+}
+
+// GetInstalledDetailed 获取已安装的所有Node.js版本及其架构信息(按版本号降序排列)
+// 各版本的架构检测并行执行(最多utility.Concurrency()个goroutine)，结果顺序与GetInstalled一致
+// 参数:
+//
+//	root: NVM安装根目录
+//
+// 返回值: 已安装版本及架构列表
+func GetInstalledDetailed(root string) []InstalledVersion {
+	installed := GetInstalled(root)
+	detailed := make([]InstalledVersion, len(installed))
+
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < utility.Concurrency() && w < len(installed); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 每个goroutine只写入自己索引的元素，无需加锁
+			for i := range jobs {
+				detailed[i] = InstalledVersion{
+					Version: installed[i],
+					Arches:  installedArches(filepath.Join(root, installed[i])),
+				}
+			}
+		}()
+	}
+	for i := range installed {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return detailed
+}
+
+// installedArches 检测版本目录中已安装的架构(内部函数)
+// 参数:
+//
+//	dir: 版本目录
+//
+// 返回值: 已安装的架构列表(按"32"/"64"/"arm64"顺序)
+func installedArches(dir string) []string {
+	found := map[string]bool{}
+	if file.Exists(filepath.Join(dir, "node32.exe")) {
+		found["32"] = true
+	}
+	if file.Exists(filepath.Join(dir, "node64.exe")) {
+		found["64"] = true
+	}
+	if bit := arch.Bit(filepath.Join(dir, "node.exe")); bit != "?" {
+		found[bit] = true
+	}
+
+	arches := []string{}
+	for _, a := range []string{"32", "64", "arm64"} {
+		if found[a] {
+			arches = append(arches, a)
+		}
+	}
+	return arches
+}
+
+// ArchMismatches 查找架构与当前操作系统不一致的已安装版本
+// 同时安装了多个架构的版本视为有意为之，不会被报告；无法识别架构的版本同样跳过
+// 参数:
+//
+//	root: NVM安装根目录
+//
+// 返回值:
+//
+//	[]string: 架构不一致的版本及其检测到的架构(如"v18.16.0 (64)")
+//	error: 根目录无法访问时返回的错误
+func ArchMismatches(root string) ([]string, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("cannot read installed versions: %w", err)
+	}
+
+	current := arch.Current()
+	mismatches := []string{}
+	for _, v := range GetInstalledDetailed(root) {
+		if len(v.Arches) != 1 {
+			continue
+		}
+		if !arch.Equal(v.Arches[0], current) {
+			mismatches = append(mismatches, fmt.Sprintf("%s (%s)", v.Version, v.Arches[0]))
+		}
+	}
+	return mismatches, nil
+}
+
+// BySemanticVersion 用于按语义化版本降序排序的字符串切片类型
+// 排序规则与nvm/semver一致，无法解析的版本排在最后
 type BySemanticVersion []string
 
 func (s BySemanticVersion) Len() int {
@@ -182,9 +491,32 @@ func (s BySemanticVersion) Swap(i, j int) {
 }
 
 func (s BySemanticVersion) Less(i, j int) bool {
-	v1, _ := semver.Make(s[i])
-	v2, _ := semver.Make(s[j])
-	return v1.GTE(v2)
+	v1, err1 := localsemver.Parse(s[i])
+	v2, err2 := localsemver.Parse(s[j])
+	if err1 != nil || err2 != nil {
+		return err1 == nil && err2 != nil
+	}
+	return v1.GT(v2)
+}
+
+// indexVersion 校验并提取index.json记录中的版本号(内部函数)
+// 参数:
+//
+//	element: 版本信息map
+//
+// 返回值:
+//
+//	string: 去掉"v"前缀的版本号
+//	bool: 记录包含有效的字符串版本号时返回true
+func indexVersion(element map[string]interface{}) (string, bool) {
+	value, ok := element["version"].(string)
+	if !ok || !strings.HasPrefix(value, "v") {
+		return "", false
+	}
+	if _, err := semver.Make(value[1:]); err != nil {
+		return "", false
+	}
+	return value[1:], true
 }
 
 // isLTS 检查版本是否为LTS(长期支持)版本(内部函数)
@@ -267,66 +599,630 @@ func isUnstable(element map[string]interface{}) bool {
 	return version.Minor%2 != 0
 }
 
-// GetAvailable 获取远程可用的Node.js版本信息
+// AvailableVersions 远程可用的Node.js版本信息(可直接序列化为JSON)
+type AvailableVersions struct {
+	All       []string          `json:"all"`       // 所有可用版本
+	LTS       []string          `json:"lts"`       // LTS版本
+	Current   []string          `json:"current"`   // 当前版本
+	Stable    []string          `json:"stable"`    // 稳定旧版本
+	Unstable  []string          `json:"unstable"`  // 不稳定旧版本
+	NPM       map[string]string `json:"npm"`       // 各版本对应的npm版本
+	Codenames map[string]string `json:"codenames"` // LTS版本对应的代号(如"Hydrogen")
+	Skipped   int               `json:"skipped"`   // 因格式错误被跳过的记录数
+}
+
+// ErrEmptyVersionList 远程版本列表为空时返回的错误
+var ErrEmptyVersionList = errors.New("Error retrieving version list")
+
+// GetAvailableVersions 获取远程可用的Node.js版本信息(使用已配置的镜像地址)
 // 返回值:
 //
-//	[]string: 所有可用版本
-//	[]string: LTS版本
-//	[]string: 当前版本
-//	[]string: 稳定旧版本
-//	[]string: 不稳定旧版本
-//	map[string]string: 各版本对应的npm版本
+//	*AvailableVersions: 分类后的版本信息
+//	error: 获取或解析过程中遇到的错误(远程返回空内容时包装ErrEmptyVersionList，离线模式下返回ErrOffline)
+func GetAvailableVersions() (*AvailableVersions, error) {
+	return GetAvailableContext(context.Background(), "")
+}
+
+// GetAvailableContext 获取远程可用的Node.js版本信息(支持取消和超时)
+// 参数:
 //
-// GetAvailable 获取所有可用的Node.js版本信息
-// 返回: all(所有版本), lts(长期支持版), current(当前版), stable(稳定版), unstable(不稳定版), npm(版本对应的npm版本)
-func GetAvailable() ([]string, []string, []string, []string, []string, map[string]string) {
-	all := make([]string, 0)
-	lts := make([]string, 0)
-	current := make([]string, 0)
-	stable := make([]string, 0)
-	unstable := make([]string, 0)
-	npm := make(map[string]string)
+//	ctx: 控制请求生命周期的上下文
+//	baseURL: 镜像地址(如"https://nodejs.org/dist/")，为空时使用已配置的镜像地址
+//
+// 返回值:
+//
+//	*AvailableVersions: 分类后的版本信息
+//	error: 获取或解析过程中遇到的错误(远程返回空内容时包装ErrEmptyVersionList，离线模式下返回ErrOffline)
+func GetAvailableContext(ctx context.Context, baseURL string) (*AvailableVersions, error) {
+	if offline {
+		return nil, ErrOffline
+	}
+
+	available := &AvailableVersions{
+		All:       make([]string, 0),
+		LTS:       make([]string, 0),
+		Current:   make([]string, 0),
+		Stable:    make([]string, 0),
+		Unstable:  make([]string, 0),
+		NPM:       make(map[string]string),
+		Codenames: make(map[string]string),
+	}
 	url := web.GetFullNodeUrl("index.json")
+	if baseURL != "" {
+		url = strings.TrimSuffix(baseURL, "/") + "/index.json"
+	}
 
 	// 从远程获取版本列表JSON文件
-	text, err := web.GetRemoteTextFile(url)
+	text, err := ContextFetcher(ctx, url)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, err
 	}
 	if len(text) == 0 {
-		fmt.Println("Error retrieving version list: \"" + url + "\" returned blank results. This can happen when the remote file is being updated. Please try again in a few minutes.")
-		os.Exit(0)
+		return nil, fmt.Errorf("%w: \"%s\" returned blank results. This can happen when the remote file is being updated. Please try again in a few minutes.", ErrEmptyVersionList, url)
 	}
 
-	// 解析JSON数据到map切片
-	var data = make([]map[string]interface{}, 0)
+	// 先解析为原始记录，单条记录格式错误时不影响其他记录
+	var data = make([]json.RawMessage, 0)
 	err = json.Unmarshal([]byte(text), &data)
 	if err != nil {
-		fmt.Printf("Error retrieving versions from \"%s\": %v", url, err.Error())
-		os.Exit(1)
+		return nil, fmt.Errorf("Error retrieving versions from \"%s\": %v", url, err.Error())
 	}
 
 	// 遍历所有版本数据并分类
-	for _, element := range data {
-		var version = element["version"].(string)[1:] // 去掉版本号前的'v'
-		all = append(all, version)
+	for _, raw := range data {
+		var element map[string]interface{}
+		if err := json.Unmarshal(raw, &element); err != nil || element == nil {
+			available.Skipped++
+			continue
+		}
+
+		version, ok := indexVersion(element)
+		if !ok {
+			available.Skipped++
+			continue
+		}
+		available.All = append(available.All, version)
 
 		if val, ok := element["npm"].(string); ok {
-			npm[version] = val // 记录版本对应的npm版本
+			available.NPM[version] = val // 记录版本对应的npm版本
+		}
+
+		// 记录LTS代号
+		if codename, ok := element["lts"].(string); ok {
+			available.Codenames[version] = codename
 		}
 
 		// 根据版本类型分类
 		if isLTS(element) {
-			lts = append(lts, version)
+			available.LTS = append(available.LTS, version)
 		} else if isCurrent(element) {
-			current = append(current, version)
+			available.Current = append(available.Current, version)
 		} else if isStable(element) {
-			stable = append(stable, version)
+			available.Stable = append(available.Stable, version)
 		} else if isUnstable(element) {
-			unstable = append(unstable, version)
+			available.Unstable = append(available.Unstable, version)
+		}
+	}
+
+	return available, nil
+}
+
+// MarshalAvailable 将可用版本信息序列化为JSON(供脚本使用)
+// 参数:
+//
+//	a: 可用版本信息
+//
+// 返回值:
+//
+//	[]byte: 包含all、lts、current、stable、unstable、npm和codenames字段的JSON
+//	error: 序列化过程中遇到的错误
+func MarshalAvailable(a *AvailableVersions) ([]byte, error) {
+	if a == nil {
+		return nil, errors.New("no version information to marshal")
+	}
+	return json.MarshalIndent(a, "", "  ")
+}
+
+// GetAvailable 获取远程可用的Node.js版本信息
+// 返回值:
+//
+//	[]string: 所有可用版本
+//	[]string: LTS版本
+//	[]string: 当前版本
+//	[]string: 稳定旧版本
+//	[]string: 不稳定旧版本
+//	map[string]string: 各版本对应的npm版本
+//
+// GetAvailable 获取所有可用的Node.js版本信息
+// 返回: all(所有版本), lts(长期支持版), current(当前版), stable(稳定版), unstable(不稳定版), npm(版本对应的npm版本)
+func GetAvailable() ([]string, []string, []string, []string, []string, map[string]string) {
+	available, err := GetAvailableVersions()
+	if err != nil {
+		fmt.Println(err)
+		if errors.Is(err, ErrEmptyVersionList) {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
+
+	if available.Skipped > 0 {
+		fmt.Printf("Warning: skipped %d malformed entries in the remote version list.\n", available.Skipped)
+	}
+
+	return available.All, available.LTS, available.Current, available.Stable, available.Unstable, available.NPM
+}
+
+// DiffVersions 比较两个版本列表，返回新增和移除的版本
+// 参数:
+//
+//	old: 旧的版本列表
+//	new: 新的版本列表
+//
+// 返回值:
+//
+//	added: new中存在但old中不存在的版本
+//	removed: old中存在但new中不存在的版本
+//
+// 结果按版本号降序排列，无法解析的版本视为普通字符串并按字典序排在最后
+func DiffVersions(old, new []string) (added, removed []string) {
+	return versionDifference(new, old), versionDifference(old, new)
+}
+
+// versionDifference 返回a中存在但b中不存在的版本(内部函数)
+// 参数:
+//
+//	a: 源版本列表
+//	b: 要排除的版本列表
+//
+// 返回值: 去重并排序后的版本列表
+func versionDifference(a, b []string) []string {
+	exclude := make(map[string]bool, len(b))
+	for _, v := range b {
+		exclude[v] = true
+	}
+
+	seen := map[string]bool{}
+	result := []string{}
+	for _, v := range a {
+		if exclude[v] || seen[v] {
+			continue
 		}
+		seen[v] = true
+		result = append(result, v)
 	}
 
-	return all, lts, current, stable, unstable, npm
+	sort.SliceStable(result, func(i, j int) bool {
+		vi, erri := semver.Make(strings.TrimPrefix(result[i], "v"))
+		vj, errj := semver.Make(strings.TrimPrefix(result[j], "v"))
+		switch {
+		case erri == nil && errj == nil:
+			if !vi.EQ(vj) {
+				return vi.GT(vj)
+			}
+			return result[i] < result[j]
+		case erri == nil:
+			return true
+		case errj == nil:
+			return false
+		default:
+			return result[i] < result[j]
+		}
+	})
+
+	return result
+}
+
+// LatestPatch 获取远程可用版本中指定主版本号和次版本号的最新修订版本
+// 参数:
+//
+//	major: 主版本号
+//	minor: 次版本号
+//
+// 返回值:
+//
+//	string: 最新修订版本(如"18.16.1")
+//	error: 获取版本列表失败或不存在匹配版本时返回的错误
+func LatestPatch(major, minor uint64) (string, error) {
+	available, err := GetAvailableVersions()
+	if err != nil {
+		return "", err
+	}
+
+	var latest *localsemver.Version
+	versions, errs := localsemver.ParseList(available.All)
+	for i, version := range versions {
+		if errs[i] != nil {
+			utility.DebugLogf("skipping unparseable version %v", errs[i])
+			continue
+		}
+		if len(version.Pre) > 0 || version.Major != major || version.Minor != minor {
+			continue
+		}
+		if latest == nil || version.GT(latest) {
+			latest = version
+		}
+	}
+
+	if latest == nil {
+		return "", fmt.Errorf("no available version matches %d.%d.x", major, minor)
+	}
+
+	return latest.String(), nil
+}
+
+// IsDowngrade 检查从from切换到to是否为降级(用于在nvm use切换到旧版本时提示用户)
+// 参数:
+//
+//	from: 当前版本(可带"v"前缀)
+//	to: 目标版本(可带"v"前缀)
+//
+// 返回值:
+//
+//	bool: to低于from时返回true
+//	error: 任一版本号无法解析时返回的错误
+func IsDowngrade(from, to string) (bool, error) {
+	current, err := localsemver.Parse(from)
+	if err != nil {
+		return false, fmt.Errorf("invalid version %q: %v", from, err)
+	}
+	target, err := localsemver.Parse(to)
+	if err != nil {
+		return false, fmt.Errorf("invalid version %q: %v", to, err)
+	}
+	return target.LT(current), nil
+}
+
+// ResolveInstallTarget 解析安装命令的版本约束，选出满足约束的最新可用版本及其下载地址
+// 参数:
+//
+//	constraint: 版本约束(如"^18"、"18.x"、">=16.0.0 <20"，语法见semver.ParseRange)
+//	cpu: 架构(32/64/arm64)，为空时使用DefaultArch
+//
+// 返回值:
+//
+//	version: 选中的版本号(如"18.20.4")
+//	url: 对应的下载地址
+//	err: 约束无效、获取版本列表失败或没有匹配的版本时返回的错误
+func ResolveInstallTarget(constraint, cpu string) (version, url string, err error) {
+	r, err := localsemver.ParseRange(constraint)
+	if err != nil {
+		return "", "", err
+	}
+
+	available, err := GetAvailableVersions()
+	if err != nil {
+		return "", "", err
+	}
+
+	var best *localsemver.Version
+	versions, _ := localsemver.ParseList(available.All)
+	for _, v := range versions {
+		if v != nil && r.Contains(v) && (best == nil || v.GT(best)) {
+			best = v
+		}
+	}
+	if best == nil {
+		return "", "", fmt.Errorf("no available version satisfies %q", constraint)
+	}
+
+	if cpu == "" {
+		cpu = DefaultArch()
+	}
+	version = best.String()
+	return version, web.GetDownloadURL(version, cpu), nil
+}
+
+// LTSByLine 获取每个LTS版本线(按代号区分)的最新版本
+// 返回值:
+//
+//	map[string]string: LTS代号(如"Hydrogen")到该版本线最新版本(如"18.20.4")的映射，
+//	                   可使用LTSCodenames按最新版本降序排列代号
+//	error: 获取远程版本列表失败时返回的错误
+func LTSByLine() (map[string]string, error) {
+	available, err := GetAvailableVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	lines := map[string]string{}
+	newest := map[string]*localsemver.Version{}
+	for version, codename := range available.Codenames {
+		v, err := localsemver.Parse(version)
+		if err != nil {
+			continue
+		}
+		if current, ok := newest[codename]; !ok || v.GT(current) {
+			newest[codename] = v
+			lines[codename] = v.String()
+		}
+	}
+	return lines, nil
+}
+
+// LTSCodenames 将LTSByLine返回的代号按各版本线最新版本降序排列
+// 参数:
+//
+//	lines: LTS代号到最新版本的映射
+//
+// 返回值: 排序后的代号列表(最新的版本线在前)
+func LTSCodenames(lines map[string]string) []string {
+	codenames := make([]string, 0, len(lines))
+	for codename := range lines {
+		codenames = append(codenames, codename)
+	}
+	sort.SliceStable(codenames, func(i, j int) bool {
+		vi, erri := localsemver.Parse(lines[codenames[i]])
+		vj, errj := localsemver.Parse(lines[codenames[j]])
+		if erri != nil || errj != nil {
+			return erri == nil
+		}
+		return vi.GT(vj)
+	})
+	return codenames
+}
+
+// ArchiveFilename 获取指定版本和架构的官方下载文件名(与SHASUMS256.txt中的名称一致)
+// 16.9.0及以上版本为zip压缩包(如"node-v18.16.0-win-x64.zip")，
+// 较早的版本为node.exe(如"win-x64/node.exe"，0.x版本为"x64/node.exe"或"node.exe")
+// 参数:
+//
+//	version: 版本号(可带"v"前缀)
+//	cpu: 架构(32/64/arm64，由arch.Validate规范化)
+//
+// 返回值:
+//
+//	string: 相对于版本目录(dist/v<version>/)的文件名
+//	error: 版本号无效时返回的错误
+func ArchiveFilename(version, cpu string) (string, error) {
+	version = strings.TrimPrefix(version, "v")
+	if _, err := localsemver.Parse(version); err != nil {
+		return "", fmt.Errorf("invalid version %q: %v", version, err)
+	}
+
+	// 与下载地址使用相同的命名规则
+	url := web.GetDownloadURL(version, arch.Validate(cpu))
+	return strings.TrimPrefix(url, web.GetFullNodeUrl("v"+version+"/")), nil
+}
+
+// DefaultArch 获取未指定架构时安装使用的默认架构
+// 默认使用操作系统的原生架构(见arch.Current)，设置了NVM_ARCH环境变量时优先使用该值(如需要在模拟环境下运行)
+// 返回值: 默认架构("arm64"/"64"/"32")
+func DefaultArch() string {
+	if override := strings.TrimSpace(os.Getenv("NVM_ARCH")); override != "" {
+		for _, a := range []string{"arm64", "64", "32"} {
+			if arch.Equal(override, a) {
+				return a
+			}
+		}
+		utility.DebugLogf("ignoring unrecognized NVM_ARCH value %q", override)
+	}
+	return arch.Current()
+}
+
+// RemoveAllExceptActive 删除除当前启用版本以外的所有已安装版本
+// 这是不可恢复的清理操作：单个版本删除失败不会中断其他版本的删除，无法确定当前启用的版本时不会删除任何版本
+// 参数:
+//
+//	root: NVM安装根目录
+//	symlinkPath: nvm符号链接路径(NVM_SYMLINK)
+//
+// 返回值:
+//
+//	[]string: 已删除的版本(如"v16.20.2")
+//	error: 无法确定当前版本，或部分版本删除失败时返回的错误(包含每个失败的版本)
+func RemoveAllExceptActive(root, symlinkPath string) ([]string, error) {
+	active, _, err := ActiveVersion(root, symlinkPath)
+	if err != nil {
+		return []string{}, fmt.Errorf("refusing to remove versions: %w", err)
+	}
+
+	removed := []string{}
+	failures := []string{}
+	for _, version := range GetInstalled(root) {
+		if strings.TrimPrefix(version, "v") == active {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, version)); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", version, err))
+			continue
+		}
+		removed = append(removed, version)
+	}
+
+	if len(failures) > 0 {
+		return removed, fmt.Errorf("failed to remove %d version(s): %s", len(failures), strings.Join(failures, "; "))
+	}
+	return removed, nil
+}
+
+// ErrInvalidVersion 版本号格式无效时返回的错误
+var ErrInvalidVersion = errors.New("invalid version")
+
+// IsValidReleaseVersion 检查版本号是否格式有效且已在远程发布
+// 参数:
+//
+//	v: 版本号(可带"v"前缀，如"v18.16.0")
+//
+// 返回值:
+//
+//	bool: 版本号有效且已发布时返回true，格式有效但未发布时返回false
+//	error: 版本号格式无效时返回包装ErrInvalidVersion的错误，获取版本列表失败时返回对应错误(离线模式下为ErrOffline)
+func IsValidReleaseVersion(v string) (bool, error) {
+	version, err := localsemver.Parse(strings.TrimPrefix(v, "v"))
+	if err != nil {
+		return false, fmt.Errorf("%w %q: %v", ErrInvalidVersion, v, err)
+	}
+
+	available, err := GetAvailableVersions()
+	if err != nil {
+		return false, err
+	}
+
+	for _, published := range available.All {
+		if published == version.String() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MajorCompatible 检查两个版本的主版本号是否相同
+// 主版本号不同时原生模块(native addons)的ABI可能不兼容，需要重新编译
+// 参数:
+//
+//	a: 版本号(可带"v"前缀)
+//	b: 版本号(可带"v"前缀)
+//
+// 返回值:
+//
+//	bool: 两个版本的主版本号相同时返回true
+//	error: 任一版本号无法解析时返回的错误
+func MajorCompatible(a, b string) (bool, error) {
+	first, err := localsemver.Parse(a)
+	if err != nil {
+		return false, fmt.Errorf("invalid version %q: %v", a, err)
+	}
+	second, err := localsemver.Parse(b)
+	if err != nil {
+		return false, fmt.Errorf("invalid version %q: %v", b, err)
+	}
+	return first.Major == second.Major, nil
+}
+
+// SetActiveArch 将指定版本当前启用的node.exe切换为指定架构
+// 通过复制node<arch>.exe原子地替换node.exe；当前node.exe没有对应的node<arch>.exe副本时会先保留一份，避免切换后丢失
+// 参数:
+//
+//	root: NVM安装根目录
+//	version: 版本号(可带"v"前缀)
+//	cpu: 目标架构(32/64/arm64)
+//
+// 返回值: 该版本未安装目标架构，或复制替换失败时返回的错误
+func SetActiveArch(root, version, cpu string) error {
+	cpu = arch.Validate(cpu)
+	dir := filepath.Join(root, "v"+strings.TrimPrefix(version, "v"))
+	exe := filepath.Join(dir, "node.exe")
+
+	active, err := ActiveArch(root, version)
+	if err == nil && active == cpu {
+		return nil
+	}
+
+	source := filepath.Join(dir, "node"+cpu+".exe")
+	if !file.Exists(source) {
+		return fmt.Errorf("the %s-bit architecture is not installed for version %s (%s not found)", cpu, version, source)
+	}
+
+	// 保留当前启用的二进制文件，便于之后切换回来
+	if err == nil {
+		backup := filepath.Join(dir, "node"+active+".exe")
+		if !file.Exists(backup) {
+			if err := utility.ReplaceFile(exe, backup); err != nil {
+				return fmt.Errorf("failed to preserve the %s-bit node.exe: %w", active, err)
+			}
+		}
+	}
+
+	return utility.ReplaceFile(source, exe)
+}
+
+// InstalledNpmVersion 获取指定Node.js版本目录中实际安装的npm版本
+// 与GetNpmVersion(来自版本索引)不同，该函数能反映用户安装后自行升级的npm
+// 优先读取node_modules/npm/package.json，无法读取时运行该目录中的npm -v
+// 参数:
+//
+//	root: NVM安装根目录
+//	nodeVersion: Node.js版本号(可带"v"前缀)
+//
+// 返回值:
+//
+//	string: npm版本号(如"9.6.7")
+//	error: 该版本目录中没有npm或无法获取版本时返回的错误
+func InstalledNpmVersion(root, nodeVersion string) (string, error) {
+	dir := filepath.Join(root, "v"+strings.TrimPrefix(nodeVersion, "v"))
+
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "node_modules", "npm", "package.json")); err == nil {
+		if json.Unmarshal(data, &pkg) == nil && pkg.Version != "" {
+			return pkg.Version, nil
+		}
+	}
+
+	npm := filepath.Join(dir, "npm.cmd")
+	if !file.Exists(npm) {
+		return "", fmt.Errorf("npm is not installed for Node.js %s", nodeVersion)
+	}
+
+	cmd := exec.Command(npm, "-v")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s -v: %v", npm, err)
+	}
+	version := strings.TrimSpace(encoding.DecodeOutput(out))
+	if version == "" {
+		return "", fmt.Errorf("%s -v returned no version", npm)
+	}
+	return version, nil
+}
+
+// LTSDrift 比较当前版本与最新LTS版本，统计当前版本落后的LTS发布数
+// 参数:
+//
+//	activeVersion: 当前使用的版本号(可带"v"前缀)
+//
+// 返回值:
+//
+//	behind: 比当前版本新的LTS发布数(当前版本为最新LTS或更新时为0)
+//	latestLTS: 最新的LTS版本号(如"20.11.1")
+//	err: 版本号无法解析或获取版本列表失败时返回的错误
+func LTSDrift(activeVersion string) (behind int, latestLTS string, err error) {
+	active, err := localsemver.Parse(activeVersion)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid version %q: %v", activeVersion, err)
+	}
+
+	available, err := GetAvailableVersions()
+	if err != nil {
+		return 0, "", err
+	}
+
+	var latest *localsemver.Version
+	versions, _ := localsemver.ParseList(available.LTS)
+	for _, v := range versions {
+		if v == nil {
+			continue
+		}
+		if latest == nil || v.GT(latest) {
+			latest = v
+		}
+		if v.GT(active) {
+			behind++
+		}
+	}
+	if latest == nil {
+		return 0, "", fmt.Errorf("%w: no LTS versions found", ErrEmptyVersionList)
+	}
+
+	return behind, latest.String(), nil
+}
+
+// NewestInstalled 获取已安装的最高版本
+// 包含预发布版本(如"v21.0.0-rc.1"高于"v20.9.0")，需要排除时使用GetInstalledFiltered
+// 参数:
+//
+//	root: NVM安装根目录
+//
+// 返回值:
+//
+//	string: 最高版本(格式同GetInstalled，如"v20.9.0")
+//	bool: 没有安装任何版本时返回false
+func NewestInstalled(root string) (string, bool) {
+	installed := GetInstalled(root)
+	if len(installed) == 0 {
+		return "", false
+	}
+	return installed[0], true
 }
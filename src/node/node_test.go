@@ -0,0 +1,86 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestBySemanticVersion(t *testing.T) {
+	versions := []string{"18.0.0-rc.1", "not-a-version", "18.0.1", "v18.0.0", "17.9.9"}
+	sort.Sort(BySemanticVersion(versions))
+
+	want := []string{"18.0.1", "v18.0.0", "18.0.0-rc.1", "17.9.9", "not-a-version"}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("sort.Sort(BySemanticVersion) = %v, want %v", versions, want)
+	}
+}
+
+func TestNormalizeInstalledCaseOnly(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"V18.0.0", "16.0.0", "v16.0.0"} {
+		if err := os.Mkdir(filepath.Join(root, dir), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	renamed, err := NormalizeInstalled(root)
+	if err != nil {
+		t.Fatalf("NormalizeInstalled() error = %v", err)
+	}
+	if want := []string{"V18.0.0"}; !reflect.DeepEqual(renamed, want) {
+		t.Errorf("NormalizeInstalled() = %v, want %v", renamed, want)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	if want := []string{"16.0.0", "v16.0.0", "v18.0.0"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("installed directories = %v, want %v", names, want)
+	}
+}
+
+func TestGetAvailableSkipsMalformedEntries(t *testing.T) {
+	index := `[
+		{"version":"v20.11.0","npm":"10.2.4","lts":"Iron"},
+		{"version":"v21.6.1","npm":"10.2.4","lts":false},
+		["v19.0.0"],
+		null,
+		{"npm":"9.0.0"},
+		{"version":"18.0.0"},
+		{"version":"vnext"},
+		{"version":18}
+	]`
+	defer SetFetcher(nil)
+	SetFetcher(func(url string) (string, error) {
+		return index, nil
+	})
+
+	available, err := GetAvailableVersions()
+	if err != nil {
+		t.Fatalf("GetAvailableVersions() = %v", err)
+	}
+	if available.Skipped != 6 {
+		t.Errorf("Skipped = %d, want 6", available.Skipped)
+	}
+	if want := []string{"20.11.0", "21.6.1"}; !reflect.DeepEqual(available.All, want) {
+		t.Errorf("All = %v, want %v", available.All, want)
+	}
+	if want := []string{"20.11.0"}; !reflect.DeepEqual(available.LTS, want) {
+		t.Errorf("LTS = %v, want %v", available.LTS, want)
+	}
+	if want := []string{"21.6.1"}; !reflect.DeepEqual(available.Current, want) {
+		t.Errorf("Current = %v, want %v", available.Current, want)
+	}
+	if available.Codenames["20.11.0"] != "Iron" || available.NPM["21.6.1"] != "10.2.4" {
+		t.Errorf("Codenames = %v, NPM = %v", available.Codenames, available.NPM)
+	}
+}
@@ -0,0 +1,135 @@
+package node
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 项目版本来源
+const (
+	SOURCE_NVMRC        = ".nvmrc"       // 来自.nvmrc文件
+	SOURCE_PACKAGE_JSON = "package.json" // 来自package.json的engines.node字段
+	SOURCE_DEFAULT      = "default"      // 来自SetDefaultVersion配置的默认版本
+)
+
+// ErrNoProjectVersion 项目目录中没有声明版本且未配置默认版本
+var ErrNoProjectVersion = errors.New("no version found in .nvmrc or package.json and no default version is configured")
+
+// defaultVersion 项目未声明版本时使用的默认版本
+var defaultVersion = ""
+
+// SetDefaultVersion 设置项目未声明版本时使用的默认版本
+// 参数:
+//
+//	version: 默认版本或版本约束(空字符串表示不使用默认版本)
+func SetDefaultVersion(version string) {
+	defaultVersion = strings.TrimSpace(version)
+}
+
+// ReadNvmrc 读取项目.nvmrc文件中声明的版本
+// 使用第一个非空且不以#开头的行
+// 参数:
+//
+//	dir: 项目目录
+//
+// 返回值:
+//
+//	string: 声明的版本，文件不存在或为空时为空字符串
+//	error: 读取过程中遇到的错误
+func ReadNvmrc(dir string) (string, error) {
+	f, err := os.Open(filepath.Join(dir, ".nvmrc"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("error reading .nvmrc: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// 去除UTF-8 BOM
+		line = strings.TrimPrefix(line, "\uFEFF")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return line, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("error reading .nvmrc: %v", err)
+	}
+
+	return "", nil
+}
+
+// ReadEngines 读取项目package.json中engines.node字段声明的版本约束
+// 参数:
+//
+//	dir: 项目目录
+//
+// 返回值:
+//
+//	string: 版本约束(如">=18")，文件或字段不存在时为空字符串
+//	error: 读取或解析package.json过程中遇到的错误
+func ReadEngines(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("error reading package.json: %v", err)
+	}
+
+	var pkg struct {
+		Engines interface{} `json:"engines"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "", fmt.Errorf("error parsing package.json: %v", err)
+	}
+
+	// 仅使用node约束，忽略npm等其他引擎
+	engines, _ := pkg.Engines.(map[string]interface{})
+	constraint, _ := engines["node"].(string)
+	return strings.TrimSpace(constraint), nil
+}
+
+// ResolveProjectVersion 按优先级解析项目所需的版本
+// 依次检查.nvmrc、package.json的engines.node字段和SetDefaultVersion配置的默认版本
+// 参数:
+//
+//	dir: 项目目录
+//
+// 返回值:
+//
+//	version: 解析到的版本或版本约束
+//	source: 版本来源(SOURCE_NVMRC/SOURCE_PACKAGE_JSON/SOURCE_DEFAULT)
+//	err: 读取失败时返回的错误，均未解析到版本时返回ErrNoProjectVersion
+func ResolveProjectVersion(dir string) (version string, source string, err error) {
+	version, err = ReadNvmrc(dir)
+	if err != nil {
+		return "", SOURCE_NVMRC, err
+	}
+	if version != "" {
+		return version, SOURCE_NVMRC, nil
+	}
+
+	version, err = ReadEngines(dir)
+	if err != nil {
+		return "", SOURCE_PACKAGE_JSON, err
+	}
+	if version != "" {
+		return version, SOURCE_PACKAGE_JSON, nil
+	}
+
+	if defaultVersion != "" {
+		return defaultVersion, SOURCE_DEFAULT, nil
+	}
+
+	return "", "", fmt.Errorf("%w (%s)", ErrNoProjectVersion, dir)
+}
@@ -0,0 +1,62 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"nvm/arch"
+	"nvm/file"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 安装校验错误(可通过errors.Is判断)
+var (
+	ErrVersionNotInstalled = errors.New("version directory not found")
+	ErrNodeMissing         = errors.New("node executable missing")
+	ErrArchMismatch        = errors.New("node executable architecture mismatch")
+	ErrNpmMissing          = errors.New("npm missing")
+)
+
+// ValidateInstall 检查版本目录的完整性
+// 参数:
+//
+//	root: NVM安装根目录
+//	version: 版本号(如"18.16.0"或"v18.16.0")
+//	cpu: 架构("32"/"64"/"arm64")
+//
+// 返回值: 目录、node可执行文件、架构或npm存在问题时返回对应的包装错误
+func ValidateInstall(root, version, cpu string) error {
+	version = strings.TrimPrefix(version, "v")
+	cpu = arch.Validate(cpu)
+	dir := filepath.Join(root, "v"+version)
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("%w: %s", ErrVersionNotInstalled, dir)
+	}
+
+	// 优先检查对应架构的备用可执行文件，否则检查node.exe
+	exe := filepath.Join(dir, "node"+cpu+".exe")
+	if cpu == "arm64" || !file.Exists(exe) {
+		exe = filepath.Join(dir, "node.exe")
+	}
+	if !file.Exists(exe) {
+		return fmt.Errorf("%w: %s", ErrNodeMissing, exe)
+	}
+
+	bit := arch.Bit(exe)
+	if bit == "?" {
+		return fmt.Errorf("%w: %s is not a valid Windows executable", ErrArchMismatch, exe)
+	}
+	if bit != cpu {
+		return fmt.Errorf("%w: %s is %s-bit, expected %s-bit", ErrArchMismatch, exe, bit, cpu)
+	}
+
+	for _, npm := range []string{filepath.Join(dir, "npm.cmd"), filepath.Join(dir, "node_modules", "npm")} {
+		if !file.Exists(npm) {
+			return fmt.Errorf("%w: %s", ErrNpmMissing, npm)
+		}
+	}
+
+	return nil
+}
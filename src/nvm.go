@@ -207,6 +207,18 @@ func init() {
 			break
 		}
 	}
+
+	// 启用离线模式，仅需本地状态时避免任何网络请求
+	// 移除该参数，以免影响后续位置参数的解析
+	args := os.Args[:1]
+	for _, arg := range os.Args[1:] {
+		if strings.ToLower(arg) == "--offline" {
+			node.SetOffline(true)
+			continue
+		}
+		args = append(args, arg)
+	}
+	os.Args = args
 }
 
 func main() {
@@ -231,6 +243,11 @@ func main() {
 
 	utility.DebugLogf("arch: %v", procarch)
 
+	// 桥接程序存在时通过桥接程序发送通知，否则使用默认的Toast通知
+	if author.Available() {
+		upgrade.SetNotifier(author.BridgeNotifier{})
+	}
+
 	if args[1] != "version" && args[1] != "--version" && args[1] != "v" && args[1] != "-v" && args[1] != "--v" {
 		setup()
 	}
@@ -326,7 +343,50 @@ func main() {
 	case "author":
 		author.Bridge(args[2:]...)
 	case "upgrade":
-		upgrade.Run(NvmVersion)
+		if len(args) > 3 && strings.ToLower(args[2]) == "verify" {
+			checksum := args[3] + ".checksum.txt"
+			if len(args) > 4 {
+				checksum = args[4]
+			}
+			if err := upgrade.VerifyDownload(args[3], checksum); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			fmt.Println("checksum verified")
+			return
+		}
+		if len(args) > 2 && (strings.ToLower(args[2]) == "backups" || strings.ToLower(args[2]) == "rollback") {
+			installDir := upgrade.InstallDir()
+			if strings.ToLower(args[2]) == "backups" {
+				backups, err := upgrade.ListBackups(installDir)
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+				if len(backups) == 0 {
+					fmt.Println("No upgrade backups available.")
+				}
+				for _, backup := range backups {
+					fmt.Printf("  %s (%s)\n", backup.Name, backup.Created.Format("2006-01-02 15:04"))
+				}
+				return
+			}
+
+			name := ""
+			if len(args) > 3 {
+				name = args[3]
+			}
+			if err := upgrade.Rollback(installDir, name); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			fmt.Println("rollback complete")
+			return
+		}
+		if err := upgrade.Run(NvmVersion); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	default:
 		fmt.Printf(`"%s" is not a valid command.`+"\n", args[1])
 		help()
@@ -589,7 +649,9 @@ func install(version string, cpuarch string) {
 				}
 
 				if !node.IsVersionInstalled(env.root, version, cpuarch) {
-					if !node.IsVersionAvailable(version) {
+					if avail, err := node.IsVersionAvailable(version); err != nil {
+						status <- Status{Err: err}
+					} else if !avail {
 						url := web.GetFullNodeUrl("index.json")
 						status <- Status{Err: fmt.Errorf("Version %s is not available.\n\nThe complete list of available versions can be found at %s", version, url)}
 					}
@@ -693,7 +755,12 @@ func install(version string, cpuarch string) {
 
 		// Check to see if the version is already installed
 		if !node.IsVersionInstalled(env.root, version, cpuarch) {
-			if !node.IsVersionAvailable(version) {
+			avail, err := node.IsVersionAvailable(version)
+			if err != nil {
+				status <- Status{Err: err}
+				return
+			}
+			if !avail {
 				url := web.GetFullNodeUrl("index.json")
 				status <- Status{Err: fmt.Errorf("Version %s is not available.\n\nThe complete list of available versions can be found at %s", version, url)}
 				return
@@ -1248,8 +1315,13 @@ func use(version string, cpuarch string, reload ...bool) {
 
 		// 创建新的符号链接
 		var ok bool
-		// 使用提升权限创建符号链接
-		ok, err = elevatedRun("mklink", "/D", filepath.Clean(env.symlink), filepath.Join(env.root, "v"+version))
+		// 优先尝试无需提升权限的方式(开发者模式下的符号链接或目录联接)，失败时使用提升权限创建符号链接
+		if err = utility.CreateSymlink(filepath.Clean(env.symlink), filepath.Join(env.root, "v"+version)); err == nil {
+			ok = true
+		} else {
+			utility.DebugLog(err.Error())
+			ok, err = elevatedRun("mklink", "/D", filepath.Clean(env.symlink), filepath.Join(env.root, "v"+version))
+		}
 		if err != nil {
 			// 处理权限不足错误
 			if strings.Contains(err.Error(), "not have sufficient privilege") || strings.Contains(strings.ToLower(err.Error()), "access is denied") {
@@ -1397,6 +1469,24 @@ func list(listtype string) {
 			fmt.Println("No installations recognized.")
 		}
 	} else {
+		// 脚本模式: 以JSON格式输出完整的可用版本信息
+		for _, arg := range os.Args[2:] {
+			if strings.ToLower(arg) == "--json" {
+				available, err := node.GetAvailableVersions()
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+				content, err := node.MarshalAvailable(available)
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+				fmt.Println(string(content))
+				return
+			}
+		}
+
 		_, lts, current, stable, unstable, _ := node.GetAvailable()
 
 		releases := 20
@@ -1760,9 +1850,8 @@ func checkLocalEnvironment() {
 	}
 
 	// Check for updates
-	colorize := true
 	if err := upgrade.EnableVirtualTerminalProcessing(); err != nil {
-		colorize = false
+		utility.SetColorEnabled(false)
 	}
 	update, checkerr := upgrade.Get()
 
@@ -1771,10 +1860,10 @@ func checkLocalEnvironment() {
 			fmt.Println("")
 		}
 		for _, warning := range update.Warnings {
-			upgrade.Warn(warning, colorize)
+			upgrade.Warn(warning)
 		}
 		for _, warning := range update.VersionWarnings {
-			upgrade.Warn(warning, colorize)
+			upgrade.Warn(warning)
 		}
 		if len(update.Warnings) > 0 || len(update.VersionWarnings) > 0 {
 			fmt.Println("")
@@ -1788,7 +1877,7 @@ func checkLocalEnvironment() {
 		if err != nil {
 			fmt.Println("Error checking for updates: " + err.Error())
 		} else if available {
-			upgrade.Warn(fmt.Sprintf("An upgrade is available: v%s", newVersion), colorize)
+			upgrade.Warn(fmt.Sprintf("An upgrade is available: v%s", newVersion))
 			fmt.Println("   run \"nvm upgrade\" to update.\n")
 		}
 	}
@@ -1808,6 +1897,7 @@ func help() {
 	fmt.Println("                                 to system arch). Set [arch] to \"all\" to install 32 AND 64 bit versions.")
 	fmt.Println("                                 Add --insecure to the end of this command to bypass SSL validation of the remote download server.")
 	fmt.Println("  nvm list [available]         : List the node.js installations. Type \"available\" at the end to see what can be installed. Aliased as ls.")
+	fmt.Println("                                 Add --json to \"nvm list available\" for machine-readable output.")
 	fmt.Println("  nvm on                       : Enable node.js version management.")
 	fmt.Println("  nvm off                      : Disable node.js version management.")
 	fmt.Println("  nvm proxy [url]              : Set a proxy to use for downloads. Leave [url] blank to see the current proxy.")
@@ -1816,6 +1906,8 @@ func help() {
 	fmt.Println("  nvm npm_mirror [url]         : Set the npm mirror. Defaults to https://github.com/npm/cli/archive/. Leave [url] blank to default url.")
 	fmt.Println("  nvm uninstall <version>      : The version must be a specific version.")
 	fmt.Println("  nvm upgrade                  : Update nvm to the latest version. Manual rollback available for 7 days after upgrade.")
+	fmt.Println("                                 Run \"nvm upgrade verify <zip> [checksum]\" to re-check a downloaded upgrade archive.")
+	fmt.Println("                                 Run \"nvm upgrade backups\" to list backups and \"nvm upgrade rollback [backup]\" to restore one.")
 	fmt.Println("  nvm use [version] [arch]     : Switch to use the specified version. Optionally use \"latest\", \"lts\", or \"newest\".")
 	fmt.Println("                                 \"newest\" is the latest installed version. Optionally specify 32/64bit architecture.")
 	fmt.Println("                                 nvm use <arch> will continue using the selected version, but switch to 32/64 bit mode.")
@@ -1827,6 +1919,7 @@ func help() {
 	fmt.Println("  nvm unsubscribe [--]<topic>  : Unsubscribe from desktop notifications.")
 	fmt.Println("                                 Valid topics: lts, current, nvm4w, author")
 	fmt.Println("  nvm [--]version              : Displays the current running version of nvm for Windows. Aliased as v.")
+	fmt.Println("  --offline                    : Add to any command to avoid network calls (remote version lookups will fail).")
 	fmt.Println(" ")
 }
 
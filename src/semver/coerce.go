@@ -0,0 +1,52 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// coercePattern 匹配文本中第一个形如"1"、"1.2"或"1.2.3"的版本号
+var coercePattern = regexp.MustCompile(`(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+// annotationPattern 匹配版本文本中的括号注释，如"1.1.12 (beta)"中的"(beta)"
+var annotationPattern = regexp.MustCompile(`\([^)]*\)`)
+
+// Coerce 从任意文本中提取第一个版本号，如"1.1.12 (beta)"、"Release v2.0.0-rc.1"
+// 先去掉括号注释，按空白拆分后用Parse严格解析每一段，成功时保留预发布版本标识和构建元数据;
+// 都无法解析时再按数字提取，缺少的次版本号和修订号按0处理，此时预发布版本标识和构建元数据会被忽略
+// 参数:
+//
+//	s: 包含版本号的文本
+//
+// 返回值:
+//
+//	*Version: 提取的版本
+//	error: 文本中没有版本号或数字溢出时返回的错误
+func Coerce(s string) (*Version, error) {
+	for _, field := range strings.Fields(annotationPattern.ReplaceAllString(s, " ")) {
+		if v, err := Parse(field); err == nil {
+			return v, nil
+		}
+	}
+
+	match := coercePattern.FindStringSubmatch(s)
+	if match == nil {
+		return nil, fmt.Errorf("No version found in %q", s)
+	}
+
+	components := [3]uint64{}
+	for i, part := range match[1:] {
+		if part == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid version number %q in %q: %v", part, s, err)
+		}
+		components[i] = n
+	}
+
+	return &Version{Major: components[0], Minor: components[1], Patch: components[2]}, nil
+}
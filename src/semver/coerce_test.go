@@ -0,0 +1,32 @@
+package semver
+
+import "testing"
+
+func TestCoerce(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1.1.12", "1.1.12"},
+		{"1.1.12 (beta)", "1.1.12"},
+		{"v2.0.0-rc.1 (preview)", "2.0.0-rc.1"},
+		{"Release v2.0.0-beta.3", "2.0.0-beta.3"},
+		{"1.2.0+build.7", "1.2.0+build.7"},
+		{"Release v2.0", "2.0.0"},
+		{"nvm 1", "1.0.0"},
+	}
+	for _, tt := range tests {
+		v, err := Coerce(tt.in)
+		if err != nil {
+			t.Errorf("Coerce(%q): %v", tt.in, err)
+			continue
+		}
+		if v.String() != tt.want {
+			t.Errorf("Coerce(%q) = %q, want %q", tt.in, v.String(), tt.want)
+		}
+	}
+
+	if _, err := Coerce("latest"); err == nil {
+		t.Error("Coerce(\"latest\") succeeded, want error")
+	}
+}
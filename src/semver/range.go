@@ -0,0 +1,540 @@
+package semver
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// bound 表示版本区间的一个端点(内部类型)
+type bound struct {
+	version   *Version // 端点版本
+	inclusive bool     // 是否包含端点
+}
+
+// interval 表示一个连续的版本区间，nil端点表示该方向无界(内部类型)
+type interval struct {
+	lower *bound // 下界
+	upper *bound // 上界
+}
+
+// Range 表示版本范围，由若干版本区间的并集组成
+// 零值(或交集为空的范围)不匹配任何版本
+type Range struct {
+	intervals []interval
+}
+
+// ParseRange 解析版本范围字符串
+// 支持的语法:
+//   - 比较运算符: =1.2.3, 1.2.3, !=1.2.3, >1.2.3, >=1.2.3, <1.2.3, <=1.2.3
+//   - 波浪号范围: ~1.2.3 (>=1.2.3 <1.3.0)
+//   - 插入符范围: ^1.2.3 (>=1.2.3 <2.0.0)
+//   - 连字符范围: 1.2.3 - 2.3.4 (>=1.2.3 <=2.3.4)
+//   - 通配符范围: 1.x (>=1.0.0 <2.0.0)、1.2.x (>=1.2.0 <1.3.0)，"x"、"X"、"*"等价，
+//     省略的部分(如"1"或"1.2")以及通配符之后的部分均视为通配符
+//   - 空格分隔表示同时满足(AND)，"||"分隔表示满足其一(OR)
+//   - "*"或空字符串匹配任意正式版本
+//
+// 参数:
+//
+//	s: 版本范围字符串
+//
+// 返回值:
+//
+//	Range: 解析后的版本范围
+//	error: 解析过程中遇到的错误
+func ParseRange(s string) (Range, error) {
+	result := Range{}
+	for _, part := range strings.Split(s, "||") {
+		set, err := parseComparatorSet(part)
+		if err != nil {
+			return Range{}, err
+		}
+		result.intervals = append(result.intervals, set.intervals...)
+	}
+	return result.normalize(), nil
+}
+
+// Contains 检查版本是否在范围内
+// 预发布版本只有在所在区间的端点也是相同主/次/修订号的预发布版本时才匹配
+// 参数:
+//
+//	v: 要检查的版本
+//
+// 返回值: 版本在范围内时返回true
+func (r Range) Contains(v *Version) bool {
+	for _, iv := range r.intervals {
+		if iv.contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty 检查范围是否不匹配任何版本
+// 返回值: 范围为空时返回true
+func (r Range) IsEmpty() bool {
+	return len(r.intervals) == 0
+}
+
+// Intersect 计算两个范围的交集
+// 参数:
+//
+//	o: 另一个版本范围
+//
+// 返回值: 同时满足两个范围的版本范围(可能为空)
+func (r Range) Intersect(o Range) Range {
+	result := Range{}
+	for _, a := range r.intervals {
+		for _, b := range o.intervals {
+			result.intervals = append(result.intervals, a.intersect(b))
+		}
+	}
+	return result.normalize()
+}
+
+// String 将范围转换为规范化的字符串
+// 区间按版本升序排列并以" || "连接，空范围输出">0.0.0 <0.0.0"
+// 返回值: 规范化后的范围字符串(可再次由ParseRange解析为等价的范围)
+func (r Range) String() string {
+	if len(r.intervals) == 0 {
+		return ">0.0.0 <0.0.0"
+	}
+
+	parts := make([]string, 0, len(r.intervals))
+	for _, iv := range r.intervals {
+		parts = append(parts, iv.String())
+	}
+	return strings.Join(parts, " || ")
+}
+
+// parseComparatorSet 解析以空格分隔的比较条件集合(内部函数)
+// 参数:
+//
+//	s: 比较条件集合字符串
+//
+// 返回值:
+//
+//	Range: 同时满足所有条件的版本范围
+//	error: 解析过程中遇到的错误
+func parseComparatorSet(s string) (Range, error) {
+	fields := strings.Fields(s)
+
+	// 连字符范围，下界省略的部分补0，上界省略的部分视为通配符
+	if len(fields) == 3 && fields[1] == "-" {
+		lower, _, err := parsePartial(fields[0])
+		if err != nil {
+			return Range{}, fmt.Errorf("Invalid range %q: %v", s, err)
+		}
+		upper, n, err := parsePartial(fields[2])
+		if err != nil {
+			return Range{}, fmt.Errorf("Invalid range %q: %v", s, err)
+		}
+		iv := interval{lower: &bound{version: lower, inclusive: true}}
+		switch {
+		case n == 3:
+			iv.upper = &bound{version: upper, inclusive: true}
+		case n > 0:
+			iv.upper = &bound{version: bumpPartial(upper, n)}
+		}
+		return Range{intervals: []interval{iv}}.normalize(), nil
+	}
+
+	// 合并运算符与版本之间有空格的写法(如">= 1.2.3")
+	comparators := []string{}
+	for i := 0; i < len(fields); i++ {
+		if strings.Trim(fields[i], "<>=!~^") == "" && i+1 < len(fields) {
+			comparators = append(comparators, fields[i]+fields[i+1])
+			i++
+			continue
+		}
+		comparators = append(comparators, fields[i])
+	}
+
+	result := Range{intervals: []interval{{}}}
+	for _, c := range comparators {
+		cr, err := parseComparator(c)
+		if err != nil {
+			return Range{}, err
+		}
+		result = result.Intersect(cr)
+	}
+	return result, nil
+}
+
+// parseComparator 解析单个比较条件(内部函数)
+// 参数:
+//
+//	s: 比较条件字符串(如">=1.2.3"、"~1.2"、"1.x")
+//
+// 返回值:
+//
+//	Range: 满足条件的版本范围
+//	error: 解析过程中遇到的错误
+func parseComparator(s string) (Range, error) {
+	op := s[:len(s)-len(strings.TrimLeft(s, "<>=!~^"))]
+	v, n, err := parsePartial(s[len(op):])
+	if err != nil {
+		return Range{}, fmt.Errorf("Invalid comparator %q: %v", s, err)
+	}
+
+	// 任意正式版本(*)、空范围以及[v, next)区间
+	all := Range{intervals: []interval{{}}}
+	none := Range{}
+	partial := func(next *Version) Range {
+		return Range{intervals: []interval{{
+			lower: &bound{version: v, inclusive: true},
+			upper: &bound{version: next},
+		}}}
+	}
+
+	switch op {
+	case "", "=", "==":
+		if n == 0 {
+			return all, nil
+		}
+		if n < 3 {
+			return partial(bumpPartial(v, n)), nil
+		}
+		return Range{intervals: []interval{{
+			lower: &bound{version: v, inclusive: true},
+			upper: &bound{version: v, inclusive: true},
+		}}}, nil
+	case "!=", "!":
+		if n == 0 {
+			return none, nil
+		}
+		if n < 3 {
+			return Range{intervals: []interval{
+				{upper: &bound{version: v}},
+				{lower: &bound{version: bumpPartial(v, n), inclusive: true}},
+			}}, nil
+		}
+		return Range{intervals: []interval{
+			{upper: &bound{version: v}},
+			{lower: &bound{version: v}},
+		}}, nil
+	case ">":
+		if n == 0 {
+			return none, nil
+		}
+		if n < 3 {
+			return Range{intervals: []interval{{lower: &bound{version: bumpPartial(v, n), inclusive: true}}}}, nil
+		}
+		return Range{intervals: []interval{{lower: &bound{version: v}}}}, nil
+	case ">=":
+		if n == 0 {
+			return all, nil
+		}
+		return Range{intervals: []interval{{lower: &bound{version: v, inclusive: true}}}}, nil
+	case "<":
+		if n == 0 {
+			return none, nil
+		}
+		return Range{intervals: []interval{{upper: &bound{version: v}}}}, nil
+	case "<=":
+		if n == 0 {
+			return all, nil
+		}
+		if n < 3 {
+			return Range{intervals: []interval{{upper: &bound{version: bumpPartial(v, n)}}}}, nil
+		}
+		return Range{intervals: []interval{{upper: &bound{version: v, inclusive: true}}}}, nil
+	case "~", "~>":
+		switch n {
+		case 0:
+			return all, nil
+		case 1:
+			return partial(bumpPartial(v, 1)), nil
+		}
+		return partial(bumpPartial(v, 2)), nil
+	case "^":
+		switch {
+		case n == 0:
+			return all, nil
+		case v.Major > 0 || n == 1:
+			return partial(bumpPartial(v, 1)), nil
+		case v.Minor > 0 || n == 2:
+			return partial(bumpPartial(v, 2)), nil
+		}
+		return partial(&Version{Patch: v.Patch + 1}), nil
+	}
+
+	return Range{}, fmt.Errorf("Invalid comparator %q: unknown operator %q", s, op)
+}
+
+// parsePartial 解析可能包含通配符或省略部分的版本号(内部函数)
+// "x"、"X"、"*"等价，通配符之后的部分一律视为通配符，未指定的部分补0
+// 参数:
+//
+//	s: 版本号字符串(如"1.2.3"、"1.x"、"1.2"、"*")
+//
+// 返回值:
+//
+//	*Version: 解析后的版本(未指定的部分为0)
+//	int: 已指定的部分数量(0-3，3表示完整版本号)
+//	error: 解析过程中遇到的错误
+func parsePartial(s string) (*Version, int, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > 0 && (s[0] == 'v' || s[0] == 'V') {
+		s = s[1:]
+	}
+
+	if s == "" {
+		return &Version{}, 0, nil
+	}
+
+	components := []uint64{}
+	for _, part := range strings.SplitN(s, ".", 3) {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		if len(components) == 2 {
+			// 修订号可能带有预发布版本或构建元数据，交由Parse处理
+			v, err := Parse(s)
+			if err != nil {
+				return nil, 0, err
+			}
+			return v, 3, nil
+		}
+		if part == "" || !containsOnly(part, numbers) || hasLeadingZeroes(part) {
+			return nil, 0, fmt.Errorf("Invalid version component %q in %q", part, s)
+		}
+		num, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, 0, err
+		}
+		components = append(components, num)
+	}
+
+	v := &Version{}
+	if len(components) > 0 {
+		v.Major = components[0]
+	}
+	if len(components) > 1 {
+		v.Minor = components[1]
+	}
+	return v, len(components), nil
+}
+
+// bumpPartial 计算通配符版本的上界(不包含)(内部函数)
+// 参数:
+//
+//	v: 通配符版本的下界
+//	n: 已指定的部分数量(1或2)
+//
+// 返回值: 上界版本(如1.x返回2.0.0，1.2.x返回1.3.0)
+func bumpPartial(v *Version, n int) *Version {
+	if n == 1 {
+		return &Version{Major: v.Major + 1}
+	}
+	return &Version{Major: v.Major, Minor: v.Minor + 1}
+}
+
+// normalize 移除空区间并合并重叠或相邻的区间(内部函数)
+// 合并会丢弃被覆盖的端点，被丢弃的端点是预发布版本时不合并，以保留该区间对预发布版本的匹配
+// 返回值: 按下界升序排列的规范化范围
+func (r Range) normalize() Range {
+	intervals := []interval{}
+	for _, iv := range r.intervals {
+		if !iv.empty() {
+			intervals = append(intervals, iv)
+		}
+	}
+
+	sort.SliceStable(intervals, func(i, j int) bool {
+		return compareLower(intervals[i].lower, intervals[j].lower) < 0
+	})
+
+	merged := []interval{}
+	for _, iv := range intervals {
+		if n := len(merged); n > 0 && merged[n-1].overlaps(iv) {
+			dropped := iv.upper
+			if compareUpper(iv.upper, merged[n-1].upper) > 0 {
+				dropped = merged[n-1].upper
+			}
+			if !iv.lower.prerelease() && !dropped.prerelease() {
+				if compareUpper(iv.upper, merged[n-1].upper) > 0 {
+					merged[n-1].upper = iv.upper
+				}
+				continue
+			}
+		}
+		merged = append(merged, iv)
+	}
+
+	return Range{intervals: merged}
+}
+
+// intersect 计算两个区间的交集(内部函数)
+// 参数:
+//
+//	o: 另一个区间
+//
+// 返回值: 交集区间(可能为空)
+func (iv interval) intersect(o interval) interval {
+	result := iv
+	if compareLower(o.lower, iv.lower) > 0 {
+		result.lower = o.lower
+	}
+	if compareUpper(o.upper, iv.upper) < 0 {
+		result.upper = o.upper
+	}
+	return result
+}
+
+// empty 检查区间是否不包含任何版本(内部函数)
+// 返回值: 区间为空时返回true
+func (iv interval) empty() bool {
+	if iv.lower == nil || iv.upper == nil {
+		return false
+	}
+	c := iv.lower.version.Compare(iv.upper.version)
+	return c > 0 || (c == 0 && !(iv.lower.inclusive && iv.upper.inclusive))
+}
+
+// overlaps 检查后一个区间是否与当前区间重叠或相邻(内部函数)
+// 调用方需保证o的下界不小于当前区间的下界
+// 参数:
+//
+//	o: 另一个区间
+//
+// 返回值: 两个区间可以合并时返回true
+func (iv interval) overlaps(o interval) bool {
+	if iv.upper == nil || o.lower == nil {
+		return true
+	}
+	c := iv.upper.version.Compare(o.lower.version)
+	return c > 0 || (c == 0 && (iv.upper.inclusive || o.lower.inclusive))
+}
+
+// contains 检查版本是否在区间内(内部函数)
+// 参数:
+//
+//	v: 要检查的版本
+//
+// 返回值: 版本在区间内时返回true
+func (iv interval) contains(v *Version) bool {
+	if iv.lower != nil {
+		c := v.Compare(iv.lower.version)
+		if c < 0 || (c == 0 && !iv.lower.inclusive) {
+			return false
+		}
+	}
+	if iv.upper != nil {
+		c := v.Compare(iv.upper.version)
+		if c > 0 || (c == 0 && !iv.upper.inclusive) {
+			return false
+		}
+	}
+
+	if len(v.Pre) > 0 {
+		return iv.lower.allowsPrerelease(v) || iv.upper.allowsPrerelease(v)
+	}
+	return true
+}
+
+// String 将区间转换为字符串(内部使用)
+// 返回值: 区间的规范化字符串
+func (iv interval) String() string {
+	if iv.lower == nil && iv.upper == nil {
+		return "*"
+	}
+	if iv.lower != nil && iv.upper != nil && iv.lower.inclusive && iv.upper.inclusive &&
+		iv.lower.version.Compare(iv.upper.version) == 0 {
+		return iv.lower.version.String()
+	}
+
+	parts := []string{}
+	if iv.lower != nil {
+		op := ">"
+		if iv.lower.inclusive {
+			op = ">="
+		}
+		parts = append(parts, op+iv.lower.version.String())
+	}
+	if iv.upper != nil {
+		op := "<"
+		if iv.upper.inclusive {
+			op = "<="
+		}
+		parts = append(parts, op+iv.upper.version.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// allowsPrerelease 检查端点是否允许匹配指定的预发布版本(内部函数)
+// 参数:
+//
+//	v: 预发布版本
+//
+// 返回值: 端点是与v主/次/修订号相同的预发布版本时返回true
+func (b *bound) allowsPrerelease(v *Version) bool {
+	return b != nil && len(b.version.Pre) > 0 &&
+		b.version.Major == v.Major && b.version.Minor == v.Minor && b.version.Patch == v.Patch
+}
+
+// prerelease 检查端点是否为预发布版本(内部函数)
+// 返回值: 端点存在且为预发布版本时返回true
+func (b *bound) prerelease() bool {
+	return b != nil && len(b.version.Pre) > 0
+}
+
+// compareLower 比较两个下界，nil表示负无穷(内部函数)
+// 版本相同时不包含端点的下界更严格(更大)
+// 参数:
+//
+//	a: 第一个下界
+//	b: 第二个下界
+//
+// 返回值: -1、0或1
+func compareLower(a, b *bound) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if c := a.version.Compare(b.version); c != 0 {
+		return c
+	}
+	if a.inclusive == b.inclusive {
+		return 0
+	}
+	if a.inclusive {
+		return -1
+	}
+	return 1
+}
+
+// compareUpper 比较两个上界，nil表示正无穷(内部函数)
+// 版本相同时不包含端点的上界更严格(更小)
+// 参数:
+//
+//	a: 第一个上界
+//	b: 第二个上界
+//
+// 返回值: -1、0或1
+func compareUpper(a, b *bound) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	if c := a.version.Compare(b.version); c != 0 {
+		return c
+	}
+	if a.inclusive == b.inclusive {
+		return 0
+	}
+	if a.inclusive {
+		return 1
+	}
+	return -1
+}
@@ -0,0 +1,81 @@
+package semver
+
+import "testing"
+
+func mustRange(t *testing.T, s string) Range {
+	t.Helper()
+	r, err := ParseRange(s)
+	if err != nil {
+		t.Fatalf("ParseRange(%q): %v", s, err)
+	}
+	return r
+}
+
+func mustVersion(t *testing.T, s string) *Version {
+	t.Helper()
+	v, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return v
+}
+
+func TestRangeContains(t *testing.T) {
+	tests := []struct {
+		r    string
+		v    string
+		want bool
+	}{
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"1.x", "1.99.0", true},
+		{"1.2.3 - 2.3", "2.3.9", true},
+		{">=1.2.3-rc.1 <1.2.4", "1.2.3-rc.2", true},
+		{">=1.0.0 <1.3.0", "1.2.3-rc.1", false},
+		{">=1.2.3-rc.1 <1.2.4 || >=1.0.0 <1.3.0", "1.2.3-rc.1", true},
+		{">=1.0.0 <1.3.0 || >=1.2.3-rc.1 <1.2.4", "1.2.3-rc.1", true},
+		{">=1.2.3-rc.1 <1.2.4 || >=1.0.0 <1.3.0", "1.2.9", true},
+	}
+	for _, tt := range tests {
+		if got := mustRange(t, tt.r).Contains(mustVersion(t, tt.v)); got != tt.want {
+			t.Errorf("ParseRange(%q).Contains(%s) = %v, want %v", tt.r, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestRangeIntersect(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		want  string
+		empty bool
+	}{
+		{">=1.0.0 <2.0.0", ">=1.5.0 <3.0.0", ">=1.5.0 <2.0.0", false},
+		{"^18", "18.x || 20.x", ">=18.0.0 <19.0.0", false},
+		{">=16.0.0", "<18.0.0 || >=20.0.0", ">=16.0.0 <18.0.0 || >=20.0.0", false},
+		{"^16", "^18", ">0.0.0 <0.0.0", true},
+		{"<1.0.0", ">1.0.0", ">0.0.0 <0.0.0", true},
+	}
+	for _, tt := range tests {
+		got := mustRange(t, tt.a).Intersect(mustRange(t, tt.b))
+		if got.String() != tt.want || got.IsEmpty() != tt.empty {
+			t.Errorf("%q ∩ %q = %q (empty %v), want %q (empty %v)", tt.a, tt.b, got.String(), got.IsEmpty(), tt.want, tt.empty)
+		}
+	}
+}
+
+func TestRangeStringRoundTrip(t *testing.T) {
+	for _, s := range []string{"^1.2.3", "1.2.3", ">=1.2.3-rc.1 <1.2.4 || >=1.0.0 <1.3.0", "*", "<1.0.0 || >=2.0.0"} {
+		r := mustRange(t, s)
+		again := mustRange(t, r.String())
+		if again.String() != r.String() {
+			t.Errorf("round trip of %q: %q != %q", s, again.String(), r.String())
+		}
+	}
+
+	empty := mustRange(t, "^16").Intersect(mustRange(t, "^18"))
+	if again := mustRange(t, empty.String()); !again.IsEmpty() {
+		t.Errorf("empty range %q re-parses as %q", empty.String(), again.String())
+	}
+}
@@ -9,6 +9,7 @@ package semver
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -63,6 +64,33 @@ func (v *Version) String() string {
 	return strings.Join(versionArray, "")
 }
 
+// Padded 将Version转换为各数字部分补零到指定宽度的版本字符串，便于列表按列对齐
+// 预发布版本标识和构建元数据原样追加，不补零
+// 参数:
+//
+//	width: 每个数字部分的最小宽度(小于等于1时与String相同)
+//
+// 返回值: 如width为2时8.9.1-rc.1返回"08.09.01-rc.1"
+func (v *Version) Padded(width int) string {
+	if width < 1 {
+		width = 1
+	}
+	pad := func(n uint64) string {
+		s := strconv.FormatUint(n, 10)
+		if len(s) < width {
+			s = strings.Repeat("0", width-len(s)) + s
+		}
+		return s
+	}
+
+	str := v.String()
+	suffix := ""
+	if i := strings.IndexAny(str, hyphen+plus); i >= 0 {
+		suffix = str[i:]
+	}
+	return pad(v.Major) + dot + pad(v.Minor) + dot + pad(v.Patch) + suffix
+}
+
 // GT 检查当前版本是否大于目标版本
 // 参数:
 //
@@ -103,6 +131,17 @@ func (v *Version) LTE(o *Version) bool {
 	return (v.Compare(o) <= 0)
 }
 
+// SatisfiesOrNewerInMajor 检查当前版本是否与最低版本属于同一主版本且不低于最低版本
+// 用于"固定在某个LTS主版本内，接受次版本和修订版本更新"的自动升级策略
+// 参数:
+//
+//	min: 最低版本
+//
+// 返回值: 主版本号相同且当前版本大于或等于min时返回true
+func (v *Version) SatisfiesOrNewerInMajor(min *Version) bool {
+	return v.Major == min.Major && v.GTE(min)
+}
+
 // Compare 比较两个版本
 // 参数:
 //
@@ -200,6 +239,95 @@ func (v *Version) Validate() error {
 	return nil
 }
 
+// IncrementPatch 修订号加1，并清除预发布版本和构建元数据
+// 返回值: 修订号溢出时返回错误
+func (v *Version) IncrementPatch() error {
+	if v.Patch == math.MaxUint64 {
+		return errors.New("Patch number overflow")
+	}
+	v.Patch++
+	v.Pre = nil
+	v.Build = nil
+	return nil
+}
+
+// IncrementMinor 次版本号加1，修订号归零，并清除预发布版本和构建元数据
+// 返回值: 次版本号溢出时返回错误
+func (v *Version) IncrementMinor() error {
+	if v.Minor == math.MaxUint64 {
+		return errors.New("Minor number overflow")
+	}
+	v.Minor++
+	v.Patch = 0
+	v.Pre = nil
+	v.Build = nil
+	return nil
+}
+
+// IncrementMajor 主版本号加1，次版本号和修订号归零，并清除预发布版本和构建元数据
+// 返回值: 主版本号溢出时返回错误
+func (v *Version) IncrementMajor() error {
+	if v.Major == math.MaxUint64 {
+		return errors.New("Major number overflow")
+	}
+	v.Major++
+	v.Minor = 0
+	v.Patch = 0
+	v.Pre = nil
+	v.Build = nil
+	return nil
+}
+
+// Bump 按指定类型递增版本号并返回新版本(原版本保持不变)
+// 参数:
+//
+//	kind: 递增类型("major"/"minor"/"patch"，不区分大小写)
+//
+// 返回值:
+//
+//	*Version: 递增后的新版本
+//	error: 类型无效、溢出或结果版本无效时返回的错误
+func (v *Version) Bump(kind string) (*Version, error) {
+	bumped := v.copy()
+
+	var err error
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "major":
+		err = bumped.IncrementMajor()
+	case "minor":
+		err = bumped.IncrementMinor()
+	case "patch":
+		err = bumped.IncrementPatch()
+	default:
+		return nil, fmt.Errorf("Invalid bump kind %q (must be major, minor, or patch)", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bumped.Validate(); err != nil {
+		return nil, err
+	}
+
+	return bumped, nil
+}
+
+// copy 创建版本的深拷贝(内部函数)
+// 返回值: 新的Version对象
+func (v *Version) copy() *Version {
+	c := &Version{
+		Major: v.Major,
+		Minor: v.Minor,
+		Patch: v.Patch,
+	}
+	for _, pre := range v.Pre {
+		p := *pre
+		c.Pre = append(c.Pre, &p)
+	}
+	c.Build = append(c.Build, v.Build...)
+	return c
+}
+
 // New 解析版本字符串并返回Version对象(Parse的别名)
 // 参数:
 //
@@ -213,6 +341,30 @@ func New(s string) (*Version, error) {
 	return Parse(s)
 }
 
+// ParseList 批量解析版本字符串，同时收集解析成功的版本和解析错误
+// 两个返回值与输入一一对应：解析成功时errs[i]为nil，失败时versions[i]为nil
+// 参数:
+//
+//	ss: 要解析的版本字符串列表
+//
+// 返回值:
+//
+//	versions: 解析后的版本列表(与ss等长)
+//	errs: 每个版本的解析错误(与ss等长)
+func ParseList(ss []string) (versions []*Version, errs []error) {
+	versions = make([]*Version, len(ss))
+	errs = make([]error, len(ss))
+	for i, s := range ss {
+		v, err := Parse(s)
+		if err != nil {
+			errs[i] = fmt.Errorf("%q: %w", s, err)
+			continue
+		}
+		versions[i] = v
+	}
+	return versions, errs
+}
+
 // Parse 解析版本字符串并返回Version对象
 // 参数:
 //
@@ -224,10 +376,14 @@ func New(s string) (*Version, error) {
 //	error: 解析过程中遇到的错误
 //
 // Parse 解析语义版本字符串并返回 Version 结构体
-// 支持的格式: vX.Y.Z[-PR][+build]
+// 支持的格式: [=][v]X.Y.Z[-PR][+build]，允许首尾空白
 func Parse(s string) (*Version, error) {
-	// 移除版本号前的'v'前缀
-	s = strings.Replace(s, "v", "", 1)
+	// 去除首尾空白，仅移除开头的'='和'v'/'V'前缀，不影响预发布版本或构建元数据中的'v'
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "=")
+	if len(s) > 0 && (s[0] == 'v' || s[0] == 'V') {
+		s = s[1:]
+	}
 	if len(s) == 0 {
 		return nil, errors.New("Version string empty")
 	}
@@ -338,7 +494,7 @@ func Parse(s string) (*Version, error) {
 
 // PRVersion 表示预发布版本信息
 type PRVersion struct {
-	VersionStr string // 字符串形式的版本标识
+	VersionStr string // 字符串形式的版本标识(超出uint64范围的数字标识也保存在此)
 	VersionNum uint64 // 数字形式的版本标识
 	IsNum      bool   // 是否为数字版本
 }
@@ -363,11 +519,14 @@ func NewPRVersion(s string) (*PRVersion, error) {
 		}
 		num, err := strconv.ParseUint(s, 10, 64)
 
-		// Might never be hit, but just in case
-		if err != nil {
+		// 规范允许任意长度的数字标识，超出uint64范围时保留原始字符串
+		if errors.Is(err, strconv.ErrRange) {
+			v.VersionStr = s
+		} else if err != nil {
 			return nil, err
+		} else {
+			v.VersionNum = num
 		}
-		v.VersionNum = num
 		v.IsNum = true
 	} else if containsOnly(s, alphanum) {
 		v.VersionStr = s
@@ -400,6 +559,9 @@ func (v *PRVersion) Compare(o *PRVersion) int {
 	} else if !v.IsNum && o.IsNum {
 		return 1
 	} else if v.IsNum && o.IsNum {
+		if v.VersionStr != "" || o.VersionStr != "" {
+			return compareNumeric(v.String(), o.String())
+		}
 		if v.VersionNum == o.VersionNum {
 			return 0
 		} else if v.VersionNum > o.VersionNum {
@@ -421,12 +583,30 @@ func (v *PRVersion) Compare(o *PRVersion) int {
 // String 将预发布版本转换为字符串
 // 返回值: 预发布版本的字符串表示
 func (v *PRVersion) String() string {
-	if v.IsNum {
+	if v.IsNum && v.VersionStr == "" {
 		return strconv.FormatUint(v.VersionNum, 10)
 	}
 	return v.VersionStr
 }
 
+// compareNumeric 比较两个无前导零的十进制数字字符串(内部函数)
+// 数字较长者更大，长度相同时按字典序比较
+// 参数:
+//
+//	a: 第一个数字字符串
+//	b: 第二个数字字符串
+//
+// 返回值: -1、0或1
+func compareNumeric(a, b string) int {
+	if len(a) != len(b) {
+		if len(a) > len(b) {
+			return 1
+		}
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
 // containsOnly 检查字符串是否只包含指定字符集中的字符(内部函数)
 // 参数:
 //
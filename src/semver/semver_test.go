@@ -0,0 +1,90 @@
+package semver
+
+import "testing"
+
+func TestParsePrefixesAndWhitespace(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"=v1.2.3", "1.2.3"},
+		{"v1.2.3", "1.2.3"},
+		{"V1.2.3", "1.2.3"},
+		{"=1.2.3", "1.2.3"},
+		{" 1.2.3 ", "1.2.3"},
+		{"\t=v1.2.3\n", "1.2.3"},
+		{"1.2.3+build.rev", "1.2.3+build.rev"},
+		{"1.2.3-dev+v8.canary", "1.2.3-dev+v8.canary"},
+	}
+	for _, tt := range tests {
+		v, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if v.String() != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.in, v.String(), tt.want)
+		}
+	}
+
+	for _, in := range []string{"1.v2.3", "vv1.2.3", "1.2.3 4"} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestLongNumericPrerelease(t *testing.T) {
+	const long = "123456789012345678901234567890"
+	v, err := Parse("1.0.0-" + long)
+	if err != nil {
+		t.Fatalf("Parse with a 30-digit prerelease: %v", err)
+	}
+	if v.String() != "1.0.0-"+long {
+		t.Errorf("String() = %q", v.String())
+	}
+	if !v.Pre[0].IsNumeric() {
+		t.Errorf("%s is not numeric", long)
+	}
+
+	ordered := []string{
+		"1.0.0-2",
+		"1.0.0-99999999999999999999",
+		"1.0.0-" + long,
+		"1.0.0-" + long + "0",
+		"1.0.0-alpha",
+		"1.0.0",
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, b := mustVersion(t, ordered[i]), mustVersion(t, ordered[i+1])
+		if !a.LT(b) || !b.GT(a) {
+			t.Errorf("expected %s < %s", a, b)
+		}
+	}
+	if mustVersion(t, "1.0.0-"+long).Compare(mustVersion(t, "1.0.0-"+long)) != 0 {
+		t.Errorf("identical long prereleases are not equal")
+	}
+}
+
+func TestSatisfiesOrNewerInMajor(t *testing.T) {
+	tests := []struct {
+		v, min string
+		want   bool
+	}{
+		{"18.16.0", "18.16.0", true},
+		{"18.20.4", "18.16.0", true},
+		{"18.16.1", "18.16.0", true},
+		{"18.15.9", "18.16.0", false},
+		{"19.0.0", "18.16.0", false},
+		{"17.99.99", "18.0.0", false},
+		{"18.0.0", "18.0.0-rc.1", true},
+		{"18.0.0-rc.1", "18.0.0", false},
+		{"0.12.18", "0.10.0", true},
+		{"1.0.0", "0.10.0", false},
+	}
+	for _, tt := range tests {
+		if got := mustVersion(t, tt.v).SatisfiesOrNewerInMajor(mustVersion(t, tt.min)); got != tt.want {
+			t.Errorf("%s.SatisfiesOrNewerInMajor(%s) = %v, want %v", tt.v, tt.min, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package semver
+
+import (
+	"sort"
+)
+
+// Versions 实现sort.Interface，按Compare的规则升序排列版本
+// 预发布版本排在同一主/次/修订号的正式版本之前(如18.0.0-rc.1 < 18.0.0 < 18.0.1)
+type Versions []*Version
+
+// Len 返回版本数量
+func (s Versions) Len() int {
+	return len(s)
+}
+
+// Swap 交换两个版本的位置
+func (s Versions) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// Less 检查第i个版本是否小于第j个版本
+func (s Versions) Less(i, j int) bool {
+	return s[i].LT(s[j])
+}
+
+// Sort 将版本按升序排列
+// 参数:
+//
+//	versions: 要排序的版本列表(原地排序)
+func Sort(versions []*Version) {
+	sort.Sort(Versions(versions))
+}
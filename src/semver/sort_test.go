@@ -0,0 +1,23 @@
+package semver
+
+import "testing"
+
+func TestSortPrereleaseBeforeRelease(t *testing.T) {
+	versions := []*Version{}
+	for _, s := range []string{"18.0.1", "18.0.0", "17.9.9", "18.0.0-rc.1", "18.0.0-rc.10", "18.0.0-rc.2"} {
+		versions = append(versions, mustVersion(t, s))
+	}
+	Sort(versions)
+
+	want := []string{"17.9.9", "18.0.0-rc.1", "18.0.0-rc.2", "18.0.0-rc.10", "18.0.0", "18.0.1"}
+	for i, v := range versions {
+		if v.String() != want[i] {
+			t.Fatalf("Sort() = %v, want %v", versions, want)
+		}
+	}
+
+	rc, release, patch := mustVersion(t, "18.0.0-rc.1"), mustVersion(t, "18.0.0"), mustVersion(t, "18.0.1")
+	if !rc.LT(release) || !release.LT(patch) {
+		t.Errorf("expected 18.0.0-rc.1 < 18.0.0 < 18.0.1")
+	}
+}
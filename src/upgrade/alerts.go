@@ -0,0 +1,270 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"nvm/node"
+	"nvm/utility"
+)
+
+// 警告严重程度
+const (
+	SEVERITY_INFO     = "info"
+	SEVERITY_WARNING  = "warning"
+	SEVERITY_ERROR    = "error"
+	SEVERITY_SECURITY = "security" // 安全修复，优先级最高
+)
+
+// severityRanks 严重程度排序(数值越大越严重)
+var severityRanks = map[string]int{
+	SEVERITY_INFO:     0,
+	SEVERITY_WARNING:  1,
+	SEVERITY_ERROR:    2,
+	SEVERITY_SECURITY: 3,
+}
+
+// securityKeywords 警告内容中表示安全修复的关键字(小写)
+var securityKeywords = []string{"security", "cve-", "vulnerab"}
+
+// MinAlertSeverity 显示警告的最低严重程度，默认只显示warning和error
+var MinAlertSeverity = SEVERITY_WARNING
+
+// SkipAlerts 检查更新时是否跳过警告信息源的下载(默认为false)
+// 跳过后Update的Warnings和VersionWarnings为空，可减少一次网络请求
+var SkipAlerts = false
+
+// SetSkipAlerts 设置检查更新时是否跳过警告信息源
+// 参数:
+//
+//	skip: 为true时不下载警告信息源
+func SetSkipAlerts(skip bool) {
+	SkipAlerts = skip
+}
+
+// Alert 表示警告信息源中的单条警告
+type Alert struct {
+	Message  string    `json:"message"`  // 警告内容
+	Link     string    `json:"link"`     // 相关链接
+	Severity string    `json:"severity"` // 严重程度(info/warning/error/security)
+	Expires  time.Time `json:"expires"`  // 过期时间(零值表示永不过期，见UnmarshalJSON)
+}
+
+// ALERT_DATE_LAYOUT 只包含日期的过期时间格式，警告在该日结束时(本地时间)过期
+const ALERT_DATE_LAYOUT = "2006-01-02"
+
+// UnmarshalJSON 解析单条警告，过期时间支持RFC3339和只包含日期("2006-01-02")两种格式
+// 参数:
+//
+//	data: 警告的JSON内容
+//
+// 返回值: 格式错误或过期时间无法识别时返回的错误
+func (a *Alert) UnmarshalJSON(data []byte) error {
+	type alertFields Alert
+	var aux struct {
+		alertFields
+		Expires string `json:"expires"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*a = Alert(aux.alertFields)
+	expires := strings.TrimSpace(aux.Expires)
+	if expires == "" {
+		return nil
+	}
+	if t, err := time.Parse(time.RFC3339, expires); err == nil {
+		a.Expires = t
+		return nil
+	}
+	t, err := time.ParseInLocation(ALERT_DATE_LAYOUT, expires, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid expiry %q: expected RFC3339 or %s", expires, ALERT_DATE_LAYOUT)
+	}
+	a.Expires = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	return nil
+}
+
+// parseAlerts 解析警告信息源(内部函数)
+// 信息源格式为 {"all": [...], "<version>": [...]}，格式错误的键和条目会被分别跳过
+// 参数:
+//
+//	body: 信息源JSON内容
+//
+// 返回值:
+//
+//	map[string][]Alert: 按键("all"或版本号)分组的警告
+//	error: 信息源整体格式无法解析时返回的错误
+func parseAlerts(body []byte) (map[string][]Alert, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return map[string][]Alert{}, fmt.Errorf("error: parsing alerts: %v", err)
+	}
+
+	alerts := make(map[string][]Alert, len(raw))
+	for key, value := range raw {
+		// 某个键的值不是数组时只跳过该键，其他键的警告仍然有效
+		var entries []json.RawMessage
+		if err := json.Unmarshal(value, &entries); err != nil {
+			utility.DebugLogf("skipping malformed alerts key %q: %v", key, err)
+			continue
+		}
+		for _, entry := range entries {
+			var alert Alert
+			if err := json.Unmarshal(entry, &alert); err != nil {
+				utility.DebugLogf("skipping malformed alert for %q: %v", key, err)
+				continue
+			}
+			if strings.TrimSpace(alert.Message) == "" {
+				utility.DebugLogf("skipping alert without a message for %q", key)
+				continue
+			}
+			alerts[key] = append(alerts[key], alert)
+		}
+	}
+
+	return alerts, nil
+}
+
+// GetVersionNotices 获取警告信息源中针对指定版本的警告(不限于最新版本)
+// 与checkForUpdate相同，已过期或低于MinAlertSeverity的警告会被过滤
+// 参数:
+//
+//	version: 版本号(可带"v"前缀)
+//
+// 返回值:
+//
+//	[]string: 警告内容列表(没有警告时返回空切片)
+//	error: 离线、下载或解析信息源过程中遇到的错误
+func GetVersionNotices(version string) ([]string, error) {
+	if node.IsOffline() {
+		return []string{}, node.ErrOffline
+	}
+
+	utility.DebugLogf("downloading alerts from %s", AlertsURL)
+	body, err := get(AlertsURL, false)
+	if err != nil {
+		return []string{}, err
+	}
+
+	alerts, err := parseAlerts(body)
+	if err != nil {
+		return []string{}, err
+	}
+
+	// 信息源中的版本键可能带有"v"前缀
+	target := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	matched := []Alert{}
+	for key, value := range alerts {
+		if key != "all" && strings.TrimPrefix(key, "v") == target {
+			matched = append(matched, value...)
+		}
+	}
+
+	return alertMessages(filterAlerts(matched, time.Now())), nil
+}
+
+// alertMessages 提取警告内容列表(内部函数)
+// 参数:
+//
+//	alerts: 警告列表
+//
+// 返回值: 警告内容列表(不会返回nil)
+func alertMessages(alerts []Alert) []string {
+	messages := []string{}
+	for _, alert := range alerts {
+		messages = append(messages, alert.Message)
+	}
+	return messages
+}
+
+// Rank 获取警告严重程度的排序值
+// 未指定或无法识别的严重程度按warning处理，以免遗漏旧格式的警告
+// 返回值: 严重程度排序值
+func (a Alert) Rank() int {
+	if rank, ok := severityRanks[strings.ToLower(strings.TrimSpace(a.Severity))]; ok {
+		return rank
+	}
+	return severityRanks[SEVERITY_WARNING]
+}
+
+// IsSecurity 检查警告是否表示安全修复
+// 严重程度为security，或内容包含securityKeywords中的关键字时返回true
+// 返回值: 警告表示安全修复时返回true
+func (a Alert) IsSecurity() bool {
+	if strings.EqualFold(strings.TrimSpace(a.Severity), SEVERITY_SECURITY) {
+		return true
+	}
+	return mentionsSecurity(a.Message)
+}
+
+// mentionsSecurity 检查文本是否包含表示安全修复的关键字(内部函数)
+// 参数:
+//
+//	text: 警告内容
+//
+// 返回值: 包含关键字时返回true
+func mentionsSecurity(text string) bool {
+	text = strings.ToLower(text)
+	for _, keyword := range securityKeywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSecurityUpdate 检查更新是否包含安全修复
+// 发布信息或警告信息源将该版本标记为安全更新，或版本警告中提到安全修复时返回true；没有相关信息时返回false
+// 返回值: 更新包含安全修复时返回true
+func (u *Update) IsSecurityUpdate() bool {
+	if u == nil {
+		return false
+	}
+	if u.Security {
+		return true
+	}
+	for _, warning := range u.VersionWarnings {
+		if mentionsSecurity(warning) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired 检查警告是否已过期
+// 参数:
+//
+//	now: 当前时间
+//
+// 返回值: 设置了过期时间且已过期时返回true
+func (a Alert) Expired(now time.Time) bool {
+	return !a.Expires.IsZero() && now.After(a.Expires)
+}
+
+// filterAlerts 过滤已过期或低于MinAlertSeverity的警告(内部函数)
+// 参数:
+//
+//	alerts: 警告列表
+//	now: 当前时间
+//
+// 返回值: 过滤后的警告列表
+func filterAlerts(alerts []Alert, now time.Time) []Alert {
+	min := Alert{Severity: MinAlertSeverity}.Rank()
+	filtered := []Alert{}
+	for _, alert := range alerts {
+		if alert.Expired(now) {
+			utility.DebugLogf("skipping expired alert: %s", alert.Message)
+			continue
+		}
+		if alert.Rank() < min {
+			utility.DebugLogf("skipping %s alert: %s", alert.Severity, alert.Message)
+			continue
+		}
+		filtered = append(filtered, alert)
+	}
+	return filtered
+}
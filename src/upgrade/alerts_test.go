@@ -0,0 +1,72 @@
+package upgrade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAlertsSkipsMalformedKeys(t *testing.T) {
+	body := []byte(`{
+		"all": [
+			{"message": "general notice", "severity": "warning"},
+			{"message": 42},
+			{"severity": "error"}
+		],
+		"1.1.12": "not a list",
+		"1.2.0": [{"message": "version notice", "severity": "error"}],
+		"1.2.1": [{"message": "bad expiry", "expires": "next tuesday"}]
+	}`)
+
+	alerts, err := parseAlerts(body)
+	if err != nil {
+		t.Fatalf("parseAlerts() error = %v", err)
+	}
+	if len(alerts["all"]) != 1 || alerts["all"][0].Message != "general notice" {
+		t.Errorf(`alerts["all"] = %+v, want only the well-formed entry`, alerts["all"])
+	}
+	if _, ok := alerts["1.1.12"]; ok {
+		t.Error(`alerts["1.1.12"] present, want the malformed key skipped`)
+	}
+	if len(alerts["1.2.0"]) != 1 {
+		t.Errorf(`alerts["1.2.0"] = %+v, want 1 alert`, alerts["1.2.0"])
+	}
+	if len(alerts["1.2.1"]) != 0 {
+		t.Errorf(`alerts["1.2.1"] = %+v, want the alert with an invalid expiry skipped`, alerts["1.2.1"])
+	}
+
+	if _, err := parseAlerts([]byte(`["not", "an", "object"]`)); err == nil {
+		t.Error("parseAlerts() on a non-object body succeeded, want error")
+	}
+}
+
+func TestParseAlertsExpiry(t *testing.T) {
+	body := []byte(`{"all": [
+		{"message": "date only", "expires": "2024-03-01"},
+		{"message": "timestamp", "expires": "2024-03-01T12:00:00Z"},
+		{"message": "never"}
+	]}`)
+
+	alerts, err := parseAlerts(body)
+	if err != nil {
+		t.Fatalf("parseAlerts() error = %v", err)
+	}
+	if len(alerts["all"]) != 3 {
+		t.Fatalf(`alerts["all"] = %+v, want 3 alerts`, alerts["all"])
+	}
+
+	dateOnly, timestamp, never := alerts["all"][0], alerts["all"][1], alerts["all"][2]
+	lastDay := time.Date(2024, time.March, 1, 23, 0, 0, 0, time.Local)
+	nextDay := time.Date(2024, time.March, 2, 0, 0, 1, 0, time.Local)
+	if dateOnly.Expired(lastDay) {
+		t.Error("date-only expiry already expired during its last day")
+	}
+	if !dateOnly.Expired(nextDay) {
+		t.Error("date-only expiry not expired on the following day")
+	}
+	if want := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC); !timestamp.Expires.Equal(want) {
+		t.Errorf("RFC3339 expiry = %v, want %v", timestamp.Expires, want)
+	}
+	if !never.Expires.IsZero() || never.Expired(nextDay) {
+		t.Errorf("alert without expiry = %v, want it to never expire", never.Expires)
+	}
+}
@@ -0,0 +1,208 @@
+package upgrade
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	BACKUP_PREFIX      = "nvm4w-backup"         // 备份文件名前缀
+	BACKUP_TIME_LAYOUT = "20060102-150405"      // 带时间戳的备份文件名中的时间格式
+	BACKUP_FLAG        = "--keep-backups="      // 指定保留备份数量的命令行参数
+	backupLegacyName   = BACKUP_PREFIX + ".zip" // 只保留一个备份时使用的文件名
+)
+
+// BackupRetention 升级时保留的备份数量(默认为1，与之前只保留最近一个备份的行为一致)
+var BackupRetention = 1
+
+// SetBackupRetention 设置升级时保留的备份数量
+// 参数:
+//
+//	n: 保留的备份数量(小于1时按1处理)
+func SetBackupRetention(n int) {
+	if n < 1 {
+		n = 1
+	}
+	BackupRetention = n
+}
+
+// Backup 表示.update目录中的一个升级备份
+type Backup struct {
+	Name    string    // 备份文件名
+	Path    string    // 备份文件完整路径
+	Created time.Time // 备份创建时间
+}
+
+// ListBackups 列出安装目录中的升级备份(按时间从新到旧排列)
+// 参数:
+//
+//	installDir: nvm安装目录
+//
+// 返回值:
+//
+//	[]Backup: 备份列表
+//	error: 读取目录过程中遇到的错误(.update目录不存在时不报错)
+func ListBackups(installDir string) ([]Backup, error) {
+	dir := filepath.Join(installDir, ".update")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Backup{}, nil
+		}
+		return []Backup{}, fmt.Errorf("error listing backups: %v", err)
+	}
+
+	backups := []Backup{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, BACKUP_PREFIX) || !strings.HasSuffix(name, ".zip") {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, BACKUP_PREFIX+"-"), ".zip")
+		created, err := time.ParseInLocation(BACKUP_TIME_LAYOUT, stamp, time.Local)
+		if err != nil {
+			// 未带时间戳的备份使用文件修改时间
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			created = info.ModTime()
+		}
+
+		backups = append(backups, Backup{Name: name, Path: filepath.Join(dir, name), Created: created})
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+
+	return backups, nil
+}
+
+// Rollback 从指定的升级备份恢复安装目录(对应"nvm upgrade rollback [backup]"命令)
+// 参数:
+//
+//	installDir: nvm安装目录
+//	name: 备份文件名(见ListBackups)，为空时使用最近的备份
+//
+// 返回值: 恢复过程中遇到的错误
+func Rollback(installDir string, name string) error {
+	backups, err := ListBackups(installDir)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return fmt.Errorf("no backup available: the default backup is only kept for 7 days after upgrading (use --keep-backups=<n> to retain more)")
+	}
+
+	backup := backups[0]
+	if name != "" {
+		found := false
+		for _, b := range backups {
+			if strings.EqualFold(b.Name, name) {
+				backup, found = b, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("backup %q not found", name)
+		}
+	}
+
+	return restoreBackup(backup.Path, installDir)
+}
+
+// restoreBackup 将备份压缩包的内容恢复到安装目录(内部函数)
+// 参数:
+//
+//	backupZip: 备份文件路径
+//	installDir: nvm安装目录
+//
+// 返回值: 恢复过程中遇到的错误
+func restoreBackup(backupZip string, installDir string) error {
+	rbtmp, err := os.MkdirTemp("", "nvm-rollback-*")
+	if err != nil {
+		return fmt.Errorf("error: failed to create rollback directory: %v", err)
+	}
+	defer os.RemoveAll(rbtmp)
+
+	if err := unzip(backupZip, rbtmp); err != nil {
+		return fmt.Errorf("error: failed to extract backup: %v", err)
+	}
+
+	// 旧版本创建的备份可能包含.update目录，恢复时跳过以免覆盖其他备份
+	os.RemoveAll(filepath.Join(rbtmp, ".update"))
+
+	// 正在运行的nvm.exe无法覆盖，但可以重命名，先将其移到.update目录中
+	if exe, err := os.Executable(); err == nil && strings.EqualFold(filepath.Clean(exe), filepath.Join(filepath.Clean(installDir), "nvm.exe")) {
+		if _, err := os.Stat(filepath.Join(rbtmp, "nvm.exe")); err == nil {
+			old := filepath.Join(installDir, ".update", "nvm.exe.old")
+			os.MkdirAll(filepath.Dir(old), os.ModePerm)
+			os.Remove(old)
+			if err := os.Rename(exe, old); err != nil {
+				return fmt.Errorf("error: failed to move the running nvm.exe aside: %v", err)
+			}
+		}
+	}
+
+	if err := copyDirContents(rbtmp, installDir); err != nil {
+		return fmt.Errorf("error: failed to restore backup files: %v", err)
+	}
+
+	return nil
+}
+
+// saveBackup 将备份保存到.update目录并清理超出BackupRetention的旧备份(内部函数)
+// 参数:
+//
+//	backupZip: 新创建的备份文件路径
+//	installDir: nvm安装目录
+//
+// 返回值: 保存过程中遇到的错误
+func saveBackup(backupZip string, installDir string) error {
+	dir := filepath.Join(installDir, ".update")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	name := backupLegacyName
+	if BackupRetention > 1 {
+		name = fmt.Sprintf("%s-%s.zip", BACKUP_PREFIX, time.Now().Format(BACKUP_TIME_LAYOUT))
+	}
+	if err := copyFile(backupZip, filepath.Join(dir, name)); err != nil {
+		return err
+	}
+
+	backups, err := ListBackups(installDir)
+	if err != nil {
+		return err
+	}
+	for i := BackupRetention; i < len(backups); i++ {
+		os.Remove(backups[i].Path)
+	}
+
+	return nil
+}
+
+// backupRetentionArg 从命令行参数中解析保留的备份数量(内部函数)
+// 参数:
+//
+//	args: 命令行参数
+//
+// 返回值: 参数中指定的数量，未指定或无效时返回BackupRetention
+func backupRetentionArg(args []string) int {
+	for _, arg := range args {
+		if strings.HasPrefix(strings.ToLower(arg), BACKUP_FLAG) {
+			if n, err := strconv.Atoi(arg[len(BACKUP_FLAG):]); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return BackupRetention
+}
@@ -7,12 +7,10 @@ import (
 	"nvm/semver"
 	"nvm/web"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/dustin/go-humanize"
-	"github.com/go-toast/toast"
 )
 
 func Check(root string, nvmversion string) {
@@ -22,6 +20,11 @@ func Check(root string, nvmversion string) {
 
 	reg := LoadRegistration(os.Args[2:]...)
 
+	if node.IsOffline() {
+		fmt.Println(node.ErrOffline)
+		return
+	}
+
 	// Check for Node.js updates
 	if reg.LTS || reg.Current {
 		buf, err := get(web.GetFullNodeUrl("index.json"))
@@ -93,28 +96,19 @@ func Check(root string, nvmversion string) {
 }
 
 func alertNvmRelease(current, next *semver.Version, data map[string]interface{}) {
-	exe, _ := os.Executable()
-	path := filepath.Dir(exe)
-	iconPath := filepath.Join(path, "nodejs.ico")
 	pubDate, _ := time.Parse("2006-01-02T15:04:05Z", data["published_at"].(string))
 	age := humanize.Time(pubDate)
 
-	notification := toast.Notification{
-		AppID:   "NVM for Windows",
+	display(Notification{
 		Title:   "NVM for Windows Update Available",
 		Message: fmt.Sprintf("Version %s is was released %s.\n(currently using v%s)", next.String(), age, current.String()),
-		Icon:    iconPath,
-		Actions: []toast.Action{
-			{"protocol", "Install", "nvm://launch?action=upgrade"},
-			{"protocol", "View", data["html_url"].(string)},
+		Icon:    "nodejs",
+		Actions: []Action{
+			{Type: "protocol", Label: "Install", URI: "nvm://launch?action=upgrade"},
+			{Type: "protocol", Label: "View", URI: data["html_url"].(string)},
 		},
-	}
-
-	// Display the notification
-	err := notification.Push()
-	if err != nil {
-		abortOnError(err)
-	}
+		Tag: "nvm4w-release-" + next.String(),
+	})
 }
 
 func in(item string, set []string) bool {
@@ -131,26 +125,16 @@ func noupdate() {
 }
 
 func UpgradeCompleteAlert(version string) {
-	exe, _ := os.Executable()
-	path := filepath.Dir(exe)
-	iconPath := filepath.Join(path, "checkmark.ico")
-
-	notification := toast.Notification{
-		AppID:   "NVM for Windows",
+	display(Notification{
 		Title:   "Upgrade Complete",
 		Message: fmt.Sprintf("The upgrade to NVM for Windows v%s completed successfully.", version),
-		Icon:    iconPath,
-		Actions: []toast.Action{
-			{"protocol", "Open PowerShell", "nvm://launch?action=open_terminal&amp;type=pwsh"},
-			{"protocol", "Open CMD Prompt", "nvm://launch?action=open_terminal&amp;type=cmd"},
+		Icon:    "checkmark",
+		Actions: []Action{
+			{Type: "protocol", Label: "Open PowerShell", URI: "nvm://launch?action=open_terminal&amp;type=pwsh"},
+			{Type: "protocol", Label: "Open CMD Prompt", URI: "nvm://launch?action=open_terminal&amp;type=cmd"},
 		},
-	}
-
-	// Display the notification
-	err := notification.Push()
-	if err != nil {
-		abortOnError(err)
-	}
+		Tag: "nvm4w-upgraded-" + version,
+	})
 }
 
 func alertRelease(data map[string]interface{}) error {
@@ -158,9 +142,6 @@ func alertRelease(data map[string]interface{}) error {
 	if err != nil {
 		return err
 	}
-	exe, _ := os.Executable()
-	path := filepath.Dir(exe)
-	iconPath := filepath.Join(path, "nodejs.ico")
 	releaseName := ""
 	releaseDate, err := time.Parse("2006-01-02", data["date"].(string))
 	if err != nil {
@@ -181,18 +162,12 @@ func alertRelease(data map[string]interface{}) error {
 
 	title := fmt.Sprintf("Node.js v%s Available%s", version.String(), releaseName)
 
-	notification := toast.Notification{
-		AppID:   "NVM for Windows",
+	display(Notification{
 		Title:   title,
 		Message: msg,
-		Icon:    iconPath,
-	}
-
-	// Display the notification
-	err = notification.Push()
-	if err != nil {
-		return err
-	}
+		Icon:    "nodejs",
+		Tag:     "node-release-" + version.String(),
+	})
 
 	return nil
 }
@@ -0,0 +1,91 @@
+package upgrade
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 临时目录的过期阈值
+const (
+	TEMP_ARTIFACT_MAX_AGE = 24 * time.Hour // 升级过程中创建的临时目录
+	// nvm4w-remove-*目录中存放着7天后执行的备份清理脚本，必须保留到计划任务执行之后
+	REMOVAL_ARTIFACT_MAX_AGE = 8 * 24 * time.Hour
+)
+
+// tempArtifactPrefixes nvm自身创建的临时目录前缀及其过期阈值
+var tempArtifactPrefixes = map[string]time.Duration{
+	"nvm-upgrade-":  TEMP_ARTIFACT_MAX_AGE,
+	"nvm-backup-":   TEMP_ARTIFACT_MAX_AGE,
+	"nvm-rollback-": TEMP_ARTIFACT_MAX_AGE,
+	"nvm4w-remove-": REMOVAL_ARTIFACT_MAX_AGE,
+	// 当前版本已不再创建以下目录，仅用于清理旧版本注册计划任务时遗留的目录
+	"nvm4w-registration-": TEMP_ARTIFACT_MAX_AGE,
+	"nvm4w-regitration-":  TEMP_ARTIFACT_MAX_AGE,
+}
+
+// CleanupTempArtifacts 清理升级失败或被中断后遗留在临时目录中的nvm临时目录
+// 仅匹配nvm自身使用的目录前缀，且只删除超过过期阈值的目录
+// 返回值:
+//
+//	removed: 已删除的目录列表
+//	err: 清理过程中遇到的错误(单个目录删除失败不会中断清理)
+func CleanupTempArtifacts() (removed []string, err error) {
+	removed = []string{}
+	tmp := os.TempDir()
+
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		return removed, fmt.Errorf("cleanup error: %v", err)
+	}
+
+	failures := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		maxAge, ok := artifactMaxAge(entry.Name())
+		if !ok {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+
+		path := filepath.Join(tmp, entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			failures = append(failures, fmt.Sprintf("%s (%v)", path, err))
+			continue
+		}
+		removed = append(removed, path)
+	}
+
+	if len(failures) > 0 {
+		return removed, fmt.Errorf("cleanup error: failed to remove %s", strings.Join(failures, ", "))
+	}
+
+	return removed, nil
+}
+
+// artifactMaxAge 根据目录名称获取对应的过期阈值(内部函数)
+// 参数:
+//
+//	name: 目录名称
+//
+// 返回值:
+//
+//	time.Duration: 过期阈值
+//	bool: 是否为nvm创建的临时目录
+func artifactMaxAge(name string) (time.Duration, bool) {
+	for prefix, maxAge := range tempArtifactPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return maxAge, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,146 @@
+package upgrade
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nvm/file"
+	"nvm/utility"
+)
+
+// 增量升级使用的发布资源
+const (
+	BINARY_ASSET   = "nvm.exe"                    // 单独发布的nvm.exe
+	MANIFEST_ASSET = "nvm-noinstall.manifest.txt" // 升级包中每个文件的校验和("<hash>  <filename>"格式，每行一个文件)
+)
+
+// downloadBinary 只有nvm.exe发生变化时，下载单独发布的nvm.exe代替完整的升级包(内部函数)
+// 满足以下条件时才使用增量升级，否则返回false以使用完整升级:
+//   - 发布同时提供BINARY_ASSET和MANIFEST_ASSET，且没有update.exe等附加资源
+//   - 未启用签名校验(分离签名只覆盖完整的升级包)
+//   - 清单中除nvm.exe以外的所有文件都已存在于安装目录中，且校验和一致
+//
+// 下载的nvm.exe会按清单中的校验和校验，并检查其版本号是否为目标版本
+// 参数:
+//
+//	update: 远程更新信息
+//	installDir: nvm安装目录
+//	tmp: 临时目录(nvm.exe保存到其中的assets目录，与完整升级解压后的位置相同)
+//	version: 当前安装的版本
+//	status: 升级状态通道
+//
+// 返回值: 已下载并校验单独发布的nvm.exe时返回true
+func downloadBinary(update *Update, installDir, tmp, version string, status chan Status) bool {
+	if update.BinaryURL == "" || update.ManifestURL == "" {
+		utility.DebugLog("the release does not provide a standalone nvm.exe, using the full upgrade")
+		return false
+	}
+	if len(update.Assets) > 0 {
+		utility.DebugLog("the release provides additional assets, using the full upgrade")
+		return false
+	}
+	if SignatureVerificationEnabled() {
+		utility.DebugLog("signature verification only covers the full archive, using the full upgrade")
+		return false
+	}
+
+	body, err := get(update.ManifestURL)
+	if err != nil {
+		utility.DebugLogf("failed to download the release manifest, using the full upgrade: %v", err)
+		return false
+	}
+	manifest, err := parseManifest(string(body))
+	if err != nil {
+		utility.DebugLogf("invalid release manifest, using the full upgrade: %v", err)
+		return false
+	}
+	expected, ok := manifest[BINARY_ASSET]
+	if !ok {
+		utility.DebugLogf("the release manifest does not list %s, using the full upgrade", BINARY_ASSET)
+		return false
+	}
+	if changed := changedFiles(manifest, installDir); len(changed) > 0 {
+		utility.DebugLogf("supporting files changed (%s), using the full upgrade", strings.Join(changed, ", "))
+		return false
+	}
+
+	status <- Status{Text: "downloading nvm.exe..."}
+	os.Mkdir(filepath.Join(tmp, "assets"), os.ModePerm)
+	binary := filepath.Join(tmp, "assets", BINARY_ASSET)
+	attempt.expected = expected
+	if err := Download(update.BinaryURL, binary, expected); err != nil {
+		utility.DebugLogf("failed to download the standalone nvm.exe, using the full upgrade: %v", err)
+		os.Remove(binary)
+		return false
+	}
+	if err := ValidateUpdateBinary(binary, version, update.Version); err != nil {
+		utility.DebugLogf("the standalone nvm.exe cannot be used, using the full upgrade: %v", err)
+		os.Remove(binary)
+		return false
+	}
+
+	utility.DebugLog("only nvm.exe changed, skipping the full archive")
+	return true
+}
+
+// parseManifest 解析升级包文件清单(内部函数)
+// 参数:
+//
+//	content: 清单内容，每行为"<hash>  <filename>"(文件名可带"*"前缀，使用"/"分隔目录)
+//
+// 返回值:
+//
+//	map[string]string: 文件相对路径 -> 校验和
+//	error: 清单为空，或包含格式错误、不安全路径的行时返回的错误
+func parseManifest(content string) (map[string]string, error) {
+	manifest := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("missing file name in manifest line %q", strings.TrimSpace(line))
+		}
+
+		name := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(strings.Join(fields[1:], " "), "*")))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(os.PathSeparator)) {
+			return nil, fmt.Errorf("unsafe path %q in manifest", name)
+		}
+		manifest[filepath.ToSlash(name)] = fields[0]
+	}
+
+	if len(manifest) == 0 {
+		return nil, fmt.Errorf("manifest is empty")
+	}
+	return manifest, nil
+}
+
+// changedFiles 找出清单中除nvm.exe以外，安装目录中不存在或校验和不一致的文件(内部函数)
+// 参数:
+//
+//	manifest: 文件相对路径 -> 校验和
+//	installDir: nvm安装目录
+//
+// 返回值: 发生变化的文件相对路径
+func changedFiles(manifest map[string]string, installDir string) []string {
+	changed := []string{}
+	for name, expected := range manifest {
+		if strings.EqualFold(name, BINARY_ASSET) {
+			continue
+		}
+
+		hasher, err := detectHashAlgo(expected)
+		if err != nil {
+			changed = append(changed, name)
+			continue
+		}
+		computed, err := file.Checksum(filepath.Join(installDir, filepath.FromSlash(name)), hasher)
+		if err != nil || !strings.EqualFold(computed, expected) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
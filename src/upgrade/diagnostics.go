@@ -0,0 +1,135 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"nvm/arch"
+	"nvm/file"
+	"nvm/utility"
+)
+
+const (
+	DIAGNOSTICS_FILE      = "upgrade-diagnostics.json" // 诊断文件名(保存在DataDir中)
+	DIAGNOSTICS_LOG_LINES = 50                         // 每个日志文件最多收集的行数
+)
+
+// Diagnostics 升级失败时收集的诊断信息
+type Diagnostics struct {
+	Timestamp      time.Time           `json:"timestamp"`                // 收集时间
+	From           string              `json:"from"`                     // 升级前版本
+	To             string              `json:"to,omitempty"`             // 目标版本
+	Error          string              `json:"error,omitempty"`          // 升级失败的原因
+	Update         *Update             `json:"update,omitempty"`         // 远程更新信息
+	Assets         []ReleaseAsset      `json:"assets,omitempty"`         // 发布中的所有资源文件
+	Expected       string              `json:"expected,omitempty"`       // 校验和文件中的值
+	Computed       string              `json:"computed,omitempty"`       // 实际计算得到的校验和
+	Arch           string              `json:"arch"`                     // 系统架构
+	ProcessArch    string              `json:"processArch"`              // 当前进程架构
+	WindowsVersion string              `json:"windowsVersion,omitempty"` // Windows版本号(major.minor.build)
+	Logs           map[string][]string `json:"logs,omitempty"`           // 日志文件路径(内存中的调试日志为"debug") -> 最近的日志行
+}
+
+// WriteDiagnostics 将最近一次升级尝试的诊断信息写入JSON文件，便于提交问题报告
+// 只收集日志文件的最后DIAGNOSTICS_LOG_LINES行，不包含完整的文件内容
+// 参数:
+//
+//	path: 诊断文件路径
+//
+// 返回值: 序列化或写入过程中遇到的错误
+func WriteDiagnostics(path string) error {
+	d := collectDiagnostics()
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, os.ModePerm)
+}
+
+// collectDiagnostics 收集当前升级尝试和运行环境的诊断信息(内部函数)
+// 返回值: 诊断信息
+func collectDiagnostics() *Diagnostics {
+	d := &Diagnostics{
+		Timestamp:   time.Now(),
+		From:        attempt.from,
+		To:          attempt.to,
+		Update:      attempt.update,
+		Assets:      attempt.update.ListReleaseAssets(),
+		Expected:    attempt.expected,
+		Error:       attempt.failure,
+		Computed:    attempt.computed,
+		Arch:        arch.Current(),
+		ProcessArch: runtime.GOARCH,
+		Logs:        map[string][]string{},
+	}
+	if major, minor, build, err := utility.WindowsVersion(); err == nil {
+		d.WindowsVersion = fmt.Sprintf("%d.%d.%d", major, minor, build)
+	}
+
+	for _, log := range diagnosticLogs() {
+		lines, err := file.ReadLines(log)
+		if err != nil {
+			continue
+		}
+		if len(lines) > DIAGNOSTICS_LOG_LINES {
+			lines = lines[len(lines)-DIAGNOSTICS_LOG_LINES:]
+		}
+		d.Logs[log] = lines
+	}
+
+	// 调试日志即使未启用--verbose也会记录在内存中
+	if lines := utility.RecentDebugLogs(); len(lines) > 0 {
+		if len(lines) > DIAGNOSTICS_LOG_LINES {
+			lines = lines[len(lines)-DIAGNOSTICS_LOG_LINES:]
+		}
+		d.Logs["debug"] = lines
+	}
+
+	return d
+}
+
+// diagnosticLogs 返回可能存在的升级日志文件路径(内部函数)
+// error.log会写入当前工作目录，更新脚本则写入安装目录
+// 返回值: 去重后的日志文件绝对路径
+func diagnosticLogs() []string {
+	dirs := []string{"."}
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exe))
+	}
+
+	logs := []string{}
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		log, err := filepath.Abs(filepath.Join(dir, "error.log"))
+		if err != nil || seen[log] || !file.Exists(log) {
+			continue
+		}
+		seen[log] = true
+		logs = append(logs, log)
+	}
+	return logs
+}
+
+// writeFailureDiagnostics 升级失败时将诊断信息写入DataDir，写入失败仅输出调试日志(内部函数)
+// 参数:
+//
+//	cause: 升级失败的原因
+func writeFailureDiagnostics(cause error) {
+	dir, err := DataDir()
+	if err != nil {
+		utility.DebugLog(err.Error())
+		return
+	}
+
+	attempt.failure = cause.Error()
+	path := filepath.Join(dir, DIAGNOSTICS_FILE)
+	if err := WriteDiagnostics(path); err != nil {
+		utility.DebugLogf("failed to write upgrade diagnostics: %v", err)
+		return
+	}
+	fmt.Printf("upgrade diagnostics written to %s (please attach this file when reporting the problem)\n", path)
+}
@@ -0,0 +1,91 @@
+package upgrade
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nvm/utility"
+
+	"github.com/dustin/go-humanize"
+)
+
+// EXTRACT_SIZE_FACTOR 估算解压后大小时使用的压缩包大小倍数
+const EXTRACT_SIZE_FACTOR = 3
+
+// checkDiskSpace 检查临时目录和安装目录所在磁盘是否有足够空间完成升级(内部函数)
+// 需要的空间包括: 下载的压缩包、解压后的文件以及当前安装目录的备份
+// 参数:
+//
+//	update: 更新信息(Size为0时跳过检查)
+//	installDir: nvm安装目录
+//
+// 返回值: 空间不足时返回包含所需和可用字节数的错误
+func checkDiskSpace(update *Update, installDir string) error {
+	if update.Size <= 0 {
+		utility.DebugLog("release size unknown, skipping disk space check")
+		return nil
+	}
+
+	download := uint64(update.Size)
+	extracted := download * EXTRACT_SIZE_FACTOR
+	backup, err := dirSize(installDir)
+	if err != nil {
+		utility.DebugLogf("unable to measure %s, skipping disk space check: %v", installDir, err)
+		return nil
+	}
+
+	// 临时目录存放下载、解压文件和备份压缩包，安装目录存放新文件和备份副本
+	tmp := os.TempDir()
+	required := map[string]uint64{
+		tmp:        download + extracted + backup,
+		installDir: extracted + backup,
+	}
+	if strings.EqualFold(filepath.VolumeName(tmp), filepath.VolumeName(installDir)) {
+		required = map[string]uint64{installDir: required[tmp] + required[installDir]}
+	}
+
+	for dir, need := range required {
+		available, err := utility.FreeSpace(dir)
+		if err != nil {
+			utility.DebugLog(err.Error())
+			continue
+		}
+		if available < need {
+			return fmt.Errorf("error: insufficient disk space on %s: %d bytes (%s) required, %d bytes (%s) available",
+				filepath.VolumeName(dir), need, humanize.Bytes(need), available, humanize.Bytes(available))
+		}
+	}
+
+	return nil
+}
+
+// dirSize 计算目录中所有文件的总大小(内部函数)
+// 参数:
+//
+//	dir: 目录路径
+//
+// 返回值:
+//
+//	uint64: 总字节数
+//	error: 遍历过程中遇到的错误
+func dirSize(dir string) (uint64, error) {
+	var size uint64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += uint64(info.Size())
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,132 @@
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// ErrElevationDeclined 用户拒绝了UAC提权请求
+var ErrElevationDeclined = errors.New("elevation request was declined")
+
+// RequiresElevation 检查写入安装目录是否需要管理员权限
+// 通过在目录中创建并删除临时文件来测试写入权限
+// 参数:
+//
+//	installDir: nvm安装目录
+//
+// 返回值:
+//
+//	bool: 没有写入权限时返回true
+//	error: 权限以外的原因导致测试失败时返回的错误
+func RequiresElevation(installDir string) (bool, error) {
+	probe, err := os.CreateTemp(installDir, ".nvm-write-test-*")
+	if err != nil {
+		if os.IsPermission(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("error checking write access to %s: %v", installDir, err)
+	}
+
+	name := probe.Name()
+	probe.Close()
+	if err := os.Remove(name); err != nil {
+		if os.IsPermission(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("error checking write access to %s: %v", installDir, err)
+	}
+
+	return false, nil
+}
+
+// IsElevated 检查当前进程是否以管理员权限运行
+// 返回值: 当前进程已提权时返回true
+func IsElevated() bool {
+	return windows.GetCurrentProcessToken().IsElevated()
+}
+
+// SEE_MASK_NOCLOSEPROCESS 使ShellExecuteEx返回新进程的句柄
+const SEE_MASK_NOCLOSEPROCESS = 0x00000040
+
+var (
+	modshell32          = windows.NewLazySystemDLL("shell32.dll")
+	procShellExecuteExW = modshell32.NewProc("ShellExecuteExW")
+)
+
+// shellExecuteInfo 对应Win32 SHELLEXECUTEINFOW结构体(内部类型)
+type shellExecuteInfo struct {
+	cbSize       uint32
+	fMask        uint32
+	hwnd         uintptr
+	lpVerb       *uint16
+	lpFile       *uint16
+	lpParameters *uint16
+	lpDirectory  *uint16
+	nShow        int32
+	hInstApp     uintptr
+	lpIDList     uintptr
+	lpClass      *uint16
+	hkeyClass    uintptr
+	dwHotKey     uint32
+	hIcon        uintptr
+	hProcess     windows.Handle
+}
+
+// RelaunchElevated 以管理员权限重新启动nvm
+// 通过ShellExecuteEx的runas动词触发UAC提示，新进程在新的控制台窗口中运行
+// 参数:
+//
+//	args: 传递给新进程的命令行参数(通常为os.Args[1:])
+//
+// 返回值:
+//
+//	*os.Process: 以管理员权限启动的新进程
+//	error: 用户拒绝UAC提示时返回ErrElevationDeclined，启动失败时返回错误
+func RelaunchElevated(args ...string) (*os.Process, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("elevation error: %v", err)
+	}
+
+	verb, _ := windows.UTF16PtrFromString("runas")
+	file, err := windows.UTF16PtrFromString(exe)
+	if err != nil {
+		return nil, fmt.Errorf("elevation error: %v", err)
+	}
+	params, err := windows.UTF16PtrFromString(windows.ComposeCommandLine(args))
+	if err != nil {
+		return nil, fmt.Errorf("elevation error: %v", err)
+	}
+	cwd, _ := windows.UTF16PtrFromString(filepath.Dir(exe))
+
+	info := &shellExecuteInfo{
+		fMask:        SEE_MASK_NOCLOSEPROCESS,
+		lpVerb:       verb,
+		lpFile:       file,
+		lpParameters: params,
+		lpDirectory:  cwd,
+		nShow:        windows.SW_SHOWNORMAL,
+	}
+	info.cbSize = uint32(unsafe.Sizeof(*info))
+	if ok, _, err := procShellExecuteExW.Call(uintptr(unsafe.Pointer(info))); ok == 0 {
+		if errors.Is(err, windows.ERROR_CANCELLED) {
+			return nil, ErrElevationDeclined
+		}
+		return nil, fmt.Errorf("elevation error: %v", err)
+	}
+	if info.hProcess == 0 {
+		return nil, errors.New("elevation error: no process was started")
+	}
+	defer windows.CloseHandle(info.hProcess)
+
+	pid, err := windows.GetProcessId(info.hProcess)
+	if err != nil {
+		return nil, fmt.Errorf("elevation error: %v", err)
+	}
+	return os.FindProcess(int(pid))
+}
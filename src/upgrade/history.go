@@ -0,0 +1,144 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"nvm/utility"
+)
+
+// UPGRADE_HISTORY_LIMIT 升级历史最多保留的记录数
+const UPGRADE_HISTORY_LIMIT = 50
+
+// UpgradeHistoryEntry 表示一次升级尝试的记录
+type UpgradeHistoryEntry struct {
+	Timestamp time.Time `json:"timestamp"`       // 升级时间
+	From      string    `json:"from"`            // 升级前版本
+	To        string    `json:"to,omitempty"`    // 目标版本(获取更新信息失败时为空)
+	Outcome   string    `json:"outcome"`         // 升级结果
+	Error     string    `json:"error,omitempty"` // 错误信息
+}
+
+// UpgradeHistory 存储升级历史记录
+type UpgradeHistory struct {
+	outpath string                // 历史文件存储路径
+	Entries []UpgradeHistoryEntry `json:"entries"` // 历史记录(按时间先后排列)
+}
+
+// Path 获取升级历史文件存储目录(没有可写入的位置时返回空字符串)
+func (h *UpgradeHistory) Path() string {
+	// 如果路径未设置，使用默认路径
+	if h.outpath == "" {
+		h.outpath, _ = DataDir()
+	}
+	return h.outpath
+}
+
+// File 获取升级历史文件完整路径(没有可写入的位置时返回空字符串)
+func (h *UpgradeHistory) File() string {
+	if h.Path() == "" {
+		return ""
+	}
+	return filepath.Join(h.Path(), ".upgrades.json")
+}
+
+// Load 从文件中加载升级历史
+// 返回值: 读取或解析过程中遇到的错误(文件不存在时不报错)
+func (h *UpgradeHistory) Load() error {
+	if h.File() == "" {
+		return ErrNoDataDir
+	}
+	data, err := os.ReadFile(h.File())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return json.Unmarshal(data, h)
+}
+
+// Append 添加一条升级记录，超过UPGRADE_HISTORY_LIMIT时丢弃最早的记录
+// 参数:
+//
+//	entry: 升级记录
+func (h *UpgradeHistory) Append(entry UpgradeHistoryEntry) {
+	h.Entries = append(h.Entries, entry)
+	if len(h.Entries) > UPGRADE_HISTORY_LIMIT {
+		h.Entries = h.Entries[len(h.Entries)-UPGRADE_HISTORY_LIMIT:]
+	}
+}
+
+// Save 将升级历史保存到文件
+// 返回值: 保存过程中遇到的错误
+func (h *UpgradeHistory) Save() error {
+	if h.Path() == "" {
+		return ErrNoDataDir
+	}
+
+	output, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// 确保目录存在
+	if err := os.MkdirAll(h.Path(), os.ModePerm); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(h.File(), output, os.ModePerm); err != nil {
+		return err
+	}
+
+	// 设置隐藏属性
+	return utility.SetHidden(h.Path(), true)
+}
+
+// upgradeAttempt 当前升级尝试的信息(内部类型)
+// 升级流程会在多个goroutine中报告结果，once确保每次尝试只记录一次
+type upgradeAttempt struct {
+	once     sync.Once
+	from     string  // 升级前版本
+	to       string  // 目标版本
+	update   *Update // 远程更新信息(获取失败时为nil)
+	expected string  // 校验和文件中的值
+	computed string  // 实际计算得到的校验和(仅校验失败时记录)
+	failure  string  // 升级失败的原因
+}
+
+// attempt 当前进程中的升级尝试
+var attempt = &upgradeAttempt{}
+
+// record 将升级结果追加到升级历史(内部函数)
+// 写入历史失败不会影响升级流程，仅输出调试日志
+// 参数:
+//
+//	result: 升级结果
+//	err: 升级过程中遇到的错误
+func (a *upgradeAttempt) record(result UpgradeResult, err error) {
+	a.once.Do(func() {
+		entry := UpgradeHistoryEntry{
+			Timestamp: time.Now(),
+			From:      a.from,
+			To:        a.to,
+			Outcome:   result.String(),
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+
+		history := &UpgradeHistory{}
+		if lerr := history.Load(); lerr != nil {
+			utility.DebugLogf("discarding unreadable upgrade history: %v", lerr)
+			history.Entries = nil
+		}
+		history.Append(entry)
+		if serr := history.Save(); serr != nil {
+			utility.DebugLogf("failed to save upgrade history: %v", serr)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package upgrade
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nvm/file"
+)
+
+// DEFAULT_ICON 找不到指定图标时使用的默认图标
+const DEFAULT_ICON = "nvm.ico"
+
+// iconAliases 通知中使用的逻辑图标名称对应的图标文件(按顺序查找)
+var iconAliases = map[string][]string{
+	"error":     {"alert.ico", "error.ico"},
+	"warning":   {"alert.ico"},
+	"success":   {"success.ico", "checkmark.ico"},
+	"checkmark": {"checkmark.ico", "success.ico"},
+	"node":      {"nodejs.ico"},
+}
+
+// resolveIcon 将逻辑图标名称(如"nvm"、"error")解析为nvm.exe所在目录中的.ico文件路径(内部函数)
+// 找不到对应的图标时使用DEFAULT_ICON
+// 参数:
+//
+//	name: 逻辑图标名称、图标文件名或已存在的图标路径
+//
+// 返回值: 图标文件的完整路径，默认图标也不存在时返回空字符串
+func resolveIcon(name string) string {
+	name = strings.TrimSpace(name)
+	if name != "" && filepath.IsAbs(name) && file.Exists(name) {
+		return name
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	dir := filepath.Dir(exe)
+
+	candidates := []string{}
+	if name != "" {
+		key := strings.ToLower(strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)))
+		candidates = append(candidates, iconAliases[key]...)
+		candidates = append(candidates, key+".ico")
+	}
+	candidates = append(candidates, DEFAULT_ICON)
+
+	for _, candidate := range candidates {
+		path := filepath.Join(dir, candidate)
+		if file.Exists(path) {
+			return path
+		}
+	}
+	return ""
+}
@@ -2,23 +2,96 @@ package upgrade
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"nvm/utility"
+
+	"golang.org/x/sys/windows"
 )
 
+// NOTIFICATION_DEDUP_WINDOW 带有相同Tag的通知的去重时间窗口
+// 计划任务每小时检查一次更新，窗口内重复的通知(如同一个"有可用升级")只显示一次
+const NOTIFICATION_DEDUP_WINDOW = 24 * time.Hour
+
+// NOTIFICATION_LOCK_TIMEOUT 等待其他进程释放通知文件锁的最长时间
+const NOTIFICATION_LOCK_TIMEOUT = 10 * time.Second
+
+// ErrNoDataDir 没有可写入nvm数据的目录
+var ErrNoDataDir = errors.New("no writable location for nvm data: APPDATA, LOCALAPPDATA and the temporary directory are unavailable")
+
+// DataDir 获取存储nvm用户数据(通知记录、升级历史等)的目录
+// 依次尝试%APPDATA%\.nvm、%LOCALAPPDATA%\.nvm和临时目录下的.nvm，
+// 计划任务以SYSTEM身份运行时APPDATA可能未设置
+// 返回值:
+//
+//	string: 可写入的数据目录(不存在时会被创建)
+//	error: 所有位置都不可写入时返回ErrNoDataDir
+func DataDir() (string, error) {
+	for _, base := range []string{os.Getenv("APPDATA"), os.Getenv("LOCALAPPDATA"), os.TempDir()} {
+		if strings.TrimSpace(base) == "" {
+			continue
+		}
+		dir := filepath.Join(base, ".nvm")
+		if writable(dir) {
+			return dir, nil
+		}
+		utility.DebugLogf("%s is not writable", dir)
+	}
+	return "", ErrNoDataDir
+}
+
+// writable 检查目录是否可写入，目录不存在时尝试创建(内部函数)
+// 参数:
+//
+//	dir: 目录路径
+//
+// 返回值: 可写入时返回true
+func writable(dir string) bool {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return false
+	}
+	probe, err := os.CreateTemp(dir, ".nvm-write-test-*")
+	if err != nil {
+		return false
+	}
+	probe.Close()
+	return os.Remove(probe.Name()) == nil
+}
+
 // LastNotification 存储最后一次通知的信息
 type LastNotification struct {
-	outpath string // 通知文件存储路径
-	LTS     string `json:"lts,omitempty"`     // 最后一次LTS版本通知日期
-	Current string `json:"current,omitempty"` // 最后一次Current版本通知日期
-	NVM4W   string `json:"nvm4w,omitempty"`   // 最后一次nvm4w更新通知日期
-	Author  string `json:"author,omitempty"`  // 作者通知信息
+	outpath string            // 通知文件存储路径
+	LTS     string            `json:"lts,omitempty"`     // 最后一次LTS版本通知日期
+	Current string            `json:"current,omitempty"` // 最后一次Current版本通知日期
+	NVM4W   string            `json:"nvm4w,omitempty"`   // 最后一次nvm4w更新通知日期
+	Author  string            `json:"author,omitempty"`  // 作者通知信息
+	Tags    map[string]string `json:"tags,omitempty"`    // 通知去重标识及最后显示时间(RFC3339)
+
+	loaded *LastNotification // 加载时的快照，Save只写入相对快照发生变化的字段
 }
 
 // LoadNotices 从文件中加载通知信息
 func LoadNotices() *LastNotification {
-	ln := &LastNotification{}
+	return loadNotices(&LastNotification{})
+}
+
+// loadNotices 从ln所在目录的通知文件中加载通知信息(内部函数)
+// 参数:
+//
+//	ln: 通知信息，outpath为空时使用DataDir
+//
+// 返回值: 加载后的ln
+func loadNotices(ln *LastNotification) *LastNotification {
+	// 没有可写入的位置时返回空记录，由Save报告错误
+	if ln.File() == "" {
+		return ln
+	}
+
 	// 读取通知文件
 	noticedata, err := os.ReadFile(ln.File())
 	if err != nil {
@@ -33,37 +106,153 @@ func LoadNotices() *LastNotification {
 		abortOnError(json.Unmarshal(noticedata, &ln))
 	}
 
+	ln.loaded = ln.snapshot()
 	return ln
 }
 
-// Path 获取通知文件存储目录
+// Path 获取通知文件存储目录(没有可写入的位置时返回空字符串)
 func (ln *LastNotification) Path() string {
 	// 如果路径未设置，使用默认路径
 	if ln.outpath == "" {
-		ln.outpath = filepath.Join(os.Getenv("APPDATA"), ".nvm")
+		ln.outpath, _ = DataDir()
 	}
 	return ln.outpath
 }
 
-// File 获取通知文件完整路径
+// File 获取通知文件完整路径(没有可写入的位置时返回空字符串)
 func (ln *LastNotification) File() string {
+	if ln.Path() == "" {
+		return ""
+	}
 	return filepath.Join(ln.Path(), ".updates.json")
 }
 
 // Save 将通知信息保存到文件
+// 多个计划任务(LTS、Current、nvm4w等)可能同时运行，因此在文件锁内重新读取文件，
+// 只写入本实例加载后修改过的字段，避免覆盖其他任务的更新
 func (ln *LastNotification) Save() {
-	// 序列化为JSON
-	output, err := json.Marshal(ln)
-	abortOnError(err)
+	// 没有可写入的位置时终止，避免写入错误的路径
+	if ln.Path() == "" {
+		abortOnError(ErrNoDataDir)
+	}
 
 	// 确保目录存在
 	abortOnError(os.MkdirAll(ln.Path(), os.ModePerm))
 
+	unlock, err := lockNotifications(ln.Path())
+	abortOnError(err)
+	defer unlock()
+
+	// 读取其他任务可能已写入的最新内容
+	latest := &LastNotification{}
+	noticedata, err := os.ReadFile(ln.File())
+	if err != nil && !os.IsNotExist(err) {
+		abortOnError(err)
+	}
+	if noticedata != nil {
+		abortOnError(json.Unmarshal(noticedata, latest))
+	}
+	latest.merge(ln)
+
+	// 序列化为JSON
+	output, err := json.Marshal(latest)
+	abortOnError(err)
+
 	// 写入文件
 	abortOnError(os.WriteFile(ln.File(), output, os.ModePerm))
 
 	// 设置隐藏属性
-	abortOnError(setHidden(ln.Path()))
+	abortOnError(utility.SetHidden(ln.Path(), true))
+
+	// 保存后以最新内容作为新的快照
+	ln.LTS, ln.Current, ln.NVM4W, ln.Author, ln.Tags = latest.LTS, latest.Current, latest.NVM4W, latest.Author, latest.Tags
+	ln.loaded = ln.snapshot()
+}
+
+// snapshot 复制当前的通知信息(内部函数)
+// 返回值: 与当前实例互不影响的副本
+func (ln *LastNotification) snapshot() *LastNotification {
+	c := &LastNotification{LTS: ln.LTS, Current: ln.Current, NVM4W: ln.NVM4W, Author: ln.Author}
+	if ln.Tags != nil {
+		c.Tags = make(map[string]string, len(ln.Tags))
+		for k, v := range ln.Tags {
+			c.Tags[k] = v
+		}
+	}
+	return c
+}
+
+// merge 将changed相对其加载快照的修改应用到当前实例(内部函数)
+// 参数:
+//
+//	changed: 被修改的通知信息(未通过LoadNotices加载时视为全部字段均已修改)
+func (ln *LastNotification) merge(changed *LastNotification) {
+	orig := changed.loaded
+	if orig == nil {
+		orig = &LastNotification{}
+	}
+
+	if changed.LTS != orig.LTS {
+		ln.LTS = changed.LTS
+	}
+	if changed.Current != orig.Current {
+		ln.Current = changed.Current
+	}
+	if changed.NVM4W != orig.NVM4W {
+		ln.NVM4W = changed.NVM4W
+	}
+	if changed.Author != orig.Author {
+		ln.Author = changed.Author
+	}
+
+	for tag, value := range changed.Tags {
+		if orig.Tags[tag] != value {
+			if ln.Tags == nil {
+				ln.Tags = map[string]string{}
+			}
+			ln.Tags[tag] = value
+		}
+	}
+	for tag := range orig.Tags {
+		if _, ok := changed.Tags[tag]; !ok {
+			delete(ln.Tags, tag)
+		}
+	}
+}
+
+// lockNotifications 获取通知文件的独占锁(内部函数)
+// 锁被其他进程持有时每隔100毫秒重试，最多等待NOTIFICATION_LOCK_TIMEOUT
+// 参数:
+//
+//	dir: 通知文件所在目录
+//
+// 返回值:
+//
+//	func(): 释放锁的函数
+//	error: 无法创建锁文件或等待超时时返回的错误
+func lockNotifications(dir string) (func(), error) {
+	f, err := os.OpenFile(filepath.Join(dir, ".updates.lock"), os.O_CREATE|os.O_RDWR, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	handle := windows.Handle(f.Fd())
+	deadline := time.Now().Add(NOTIFICATION_LOCK_TIMEOUT)
+	for {
+		ol := new(windows.Overlapped)
+		err = windows.LockFileEx(handle, windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+		if err == nil {
+			return func() {
+				windows.UnlockFileEx(handle, 0, 1, 0, new(windows.Overlapped))
+				f.Close()
+			}, nil
+		}
+		if err != windows.ERROR_LOCK_VIOLATION || time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock notification file: %w", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
 }
 
 // LastLTS 获取最后一次LTS通知的时间
@@ -89,3 +278,82 @@ func (ln *LastNotification) LastCurrent() time.Time {
 	t, _ := time.Parse("2006-01-02", ln.Current)
 	return t
 }
+
+// Seen 检查带有指定标识的通知是否在去重窗口内显示过
+// 参数:
+//
+//	tag: 通知去重标识
+//	now: 当前时间
+//
+// 返回值: 在NOTIFICATION_DEDUP_WINDOW内显示过时返回true
+func (ln *LastNotification) Seen(tag string, now time.Time) bool {
+	last, err := time.Parse(time.RFC3339, ln.Tags[tag])
+	if err != nil {
+		return false
+	}
+	return now.Sub(last) < NOTIFICATION_DEDUP_WINDOW
+}
+
+// Mark 记录带有指定标识的通知的显示时间，并清除已超出去重窗口的记录
+// 参数:
+//
+//	tag: 通知去重标识
+//	now: 当前时间
+func (ln *LastNotification) Mark(tag string, now time.Time) {
+	if ln.Tags == nil {
+		ln.Tags = map[string]string{}
+	}
+	for t := range ln.Tags {
+		if !ln.Seen(t, now) {
+			delete(ln.Tags, t)
+		}
+	}
+	ln.Tags[tag] = now.Format(time.RFC3339)
+}
+
+// markNotification 检查并记录通知去重标识(内部函数)
+// 与LoadNotices/Save不同，读写失败时不会终止程序，只输出调试日志
+// 参数:
+//
+//	tag: 通知去重标识
+//	now: 当前时间
+//
+// 返回值: 通知应该显示时返回true
+func markNotification(tag string, now time.Time) bool {
+	ln := &LastNotification{}
+	if ln.Path() == "" {
+		utility.DebugLog(ErrNoDataDir.Error())
+		return true
+	}
+	if err := os.MkdirAll(ln.Path(), os.ModePerm); err != nil {
+		utility.DebugLogf("failed to save notification history: %v", err)
+		return true
+	}
+	unlock, err := lockNotifications(ln.Path())
+	if err != nil {
+		utility.DebugLog(err.Error())
+		return true
+	}
+	defer unlock()
+
+	if data, err := os.ReadFile(ln.File()); err == nil {
+		if err := json.Unmarshal(data, ln); err != nil {
+			utility.DebugLogf("ignoring unreadable notification history: %v", err)
+		}
+	}
+
+	if ln.Seen(tag, now) {
+		return false
+	}
+	ln.Mark(tag, now)
+
+	output, err := json.Marshal(ln)
+	if err == nil {
+		err = os.WriteFile(ln.File(), output, os.ModePerm)
+	}
+	if err != nil {
+		utility.DebugLogf("failed to save notification history: %v", err)
+	}
+
+	return true
+}
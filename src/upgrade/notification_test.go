@@ -0,0 +1,112 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSaveConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	fields := map[string]func(ln *LastNotification){
+		"lts":     func(ln *LastNotification) { ln.LTS = "2024-01-01" },
+		"current": func(ln *LastNotification) { ln.Current = "2024-01-02" },
+		"nvm4w":   func(ln *LastNotification) { ln.NVM4W = "1.2.0" },
+		"author":  func(ln *LastNotification) { ln.Author = "notice" },
+	}
+	const tags = 8
+
+	// Every task loads before any of them saves, as when the scheduled tasks start together
+	var pending []*LastNotification
+	for _, set := range fields {
+		ln := loadNotices(&LastNotification{outpath: dir})
+		set(ln)
+		pending = append(pending, ln)
+	}
+	for i := 0; i < tags; i++ {
+		ln := loadNotices(&LastNotification{outpath: dir})
+		ln.Tags = map[string]string{fmt.Sprintf("tag-%d", i): "2024-01-01T00:00:00Z"}
+		pending = append(pending, ln)
+	}
+
+	var wg sync.WaitGroup
+	for _, ln := range pending {
+		wg.Add(1)
+		go func(ln *LastNotification) {
+			defer wg.Done()
+			ln.Save()
+		}(ln)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(filepath.Join(dir, ".updates.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved LastNotification
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	want := LastNotification{LTS: "2024-01-01", Current: "2024-01-02", NVM4W: "1.2.0", Author: "notice", Tags: map[string]string{}}
+	for i := 0; i < tags; i++ {
+		want.Tags[fmt.Sprintf("tag-%d", i)] = "2024-01-01T00:00:00Z"
+	}
+	if !reflect.DeepEqual(saved, want) {
+		t.Errorf(".updates.json = %+v, want %+v", saved, want)
+	}
+}
+
+func TestMergeDeletesTag(t *testing.T) {
+	changed := &LastNotification{Tags: map[string]string{"old": "a", "kept": "b"}}
+	changed.loaded = changed.snapshot()
+	delete(changed.Tags, "old")
+
+	// "other" was written by another task after changed was loaded
+	latest := &LastNotification{Tags: map[string]string{"old": "a", "kept": "b", "other": "c"}}
+	latest.merge(changed)
+
+	want := map[string]string{"kept": "b", "other": "c"}
+	if !reflect.DeepEqual(latest.Tags, want) {
+		t.Errorf("Tags = %v, want %v", latest.Tags, want)
+	}
+}
+
+func TestDataDirWithoutAppData(t *testing.T) {
+	local := t.TempDir()
+	t.Setenv("APPDATA", "")
+	t.Setenv("LOCALAPPDATA", local)
+
+	dir, err := DataDir()
+	if err != nil {
+		t.Fatalf("DataDir() error = %v", err)
+	}
+	if want := filepath.Join(local, ".nvm"); dir != want {
+		t.Errorf("DataDir() = %q, want %q", dir, want)
+	}
+
+	ln := &LastNotification{}
+	if file := ln.File(); !filepath.IsAbs(file) || !strings.HasPrefix(file, local) {
+		t.Errorf("File() = %q, want a path under %q", file, local)
+	}
+}
+
+func TestDataDirUnwritable(t *testing.T) {
+	// A regular file cannot contain the .nvm directory
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"APPDATA", "LOCALAPPDATA", "TMP", "TEMP"} {
+		t.Setenv(key, blocker)
+	}
+
+	if dir, err := DataDir(); !errors.Is(err, ErrNoDataDir) {
+		t.Errorf("DataDir() = %q, %v, want ErrNoDataDir", dir, err)
+	}
+}
@@ -0,0 +1,47 @@
+package upgrade
+
+import (
+	"fmt"
+)
+
+// Notifier 通知发送后端，display通过当前配置的Notifier发送通知
+type Notifier interface {
+	// Notify 发送一条通知
+	Notify(data Notification) error
+}
+
+// ConsoleNotifier 将通知输出到控制台，适用于没有桌面会话的环境
+type ConsoleNotifier struct{}
+
+// Notify 将通知的标题、内容和链接输出到标准输出
+// 参数:
+//
+//	data: 通知内容
+//
+// 返回值: 始终返回nil
+func (ConsoleNotifier) Notify(data Notification) error {
+	if data.Title != "" {
+		fmt.Println(highlight(data.Title))
+	}
+	if data.Message != "" {
+		fmt.Println(data.Message)
+	}
+	if data.Link != "" {
+		fmt.Println(data.Link)
+	}
+	return nil
+}
+
+// notifier 当前使用的通知后端
+var notifier Notifier = ToastNotifier{}
+
+// SetNotifier 设置发送通知使用的后端(如author.BridgeNotifier)
+// 参数:
+//
+//	n: 通知后端，为nil时恢复默认的ToastNotifier
+func SetNotifier(n Notifier) {
+	if n == nil {
+		n = ToastNotifier{}
+	}
+	notifier = n
+}
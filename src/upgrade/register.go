@@ -3,11 +3,21 @@
 package upgrade
 
 import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"nvm/encoding"
+	"nvm/utility"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
 )
 
 // 计划任务名称常量
@@ -18,6 +28,55 @@ const (
 	AUTHOR_SCHEDULE_NAME       = "NVM for Windows Author Update Check"          // 作者更新检查任务名
 )
 
+// schtasks重试设置
+const (
+	SCHTASKS_ATTEMPTS    = 3                      // schtasks执行失败时的最大尝试次数
+	SCHTASKS_RETRY_DELAY = 500 * time.Millisecond // 两次尝试之间的等待时间
+)
+
+// permanentTaskErrors 重试也无法解决的schtasks错误(拒绝访问、参数无效、任务不存在)
+// schtasks对几乎所有错误都返回退出码1，并输出系统错误消息(随系统语言本地化)，
+// 因此除退出码外，还会与FormatMessage得到的本地化消息比较来识别错误码
+var permanentTaskErrors = []windows.Errno{
+	windows.ERROR_ACCESS_DENIED,
+	windows.ERROR_INVALID_PARAMETER,
+	windows.ERROR_FILE_NOT_FOUND,
+}
+
+// ErrTaskNotFound 计划任务未注册
+var ErrTaskNotFound = errors.New("scheduled task not found")
+
+// ErrNoTaskSelected 没有通过参数选择任何计划任务类型
+var ErrNoTaskSelected = errors.New("scheduling error: no task type selected (use --lts, --current, --nvm4w and/or --author)")
+
+// nextRunLayouts 无歧义的下次运行时间格式，在无法读取区域设置时使用
+// 日/月顺序因区域而异(如"1/2/2006"既可能是1月2日也可能是2月1日)，这类格式只能按区域设置解析
+var nextRunLayouts = []string{
+	"2006/1/2 15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// 区域设置信息类型(GetLocaleInfoEx)
+const (
+	localeShortDate  = 0x1F   // LOCALE_SSHORTDATE 短日期格式，如"M/d/yyyy"
+	localeTimeFormat = 0x1003 // LOCALE_STIMEFORMAT 时间格式，如"h:mm:ss tt"
+	localeAM         = 0x28   // LOCALE_S1159 上午标识
+	localePM         = 0x29   // LOCALE_S2359 下午标识
+)
+
+// localeTokens Windows日期时间格式标记对应的Go时间格式(按长度从长到短匹配)
+var localeTokens = []struct {
+	token  string
+	layout string
+}{
+	{"dddd", "Monday"}, {"ddd", "Mon"}, {"dd", "02"}, {"d", "2"},
+	{"MMMM", "January"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"},
+	{"yyyyy", "2006"}, {"yyyy", "2006"}, {"yy", "06"}, {"y", "06"},
+	{"hh", "03"}, {"h", "3"}, {"HH", "15"}, {"H", "15"},
+	{"mm", "04"}, {"m", "4"}, {"ss", "05"}, {"s", "5"},
+	{"tt", "PM"}, {"t", "PM"},
+}
+
 // Registration 表示需要注册的计划任务类型
 type Registration struct {
 	LTS     bool // 是否注册 Node.js LTS 版本更新检查
@@ -60,6 +119,12 @@ func LoadRegistration(args ...string) *Registration {
 	return reg
 }
 
+// Any 检查是否选择了至少一种计划任务类型
+// 返回值: 选择了任意任务类型时返回true
+func (r *Registration) Any() bool {
+	return r.LTS || r.Current || r.NVM4W || r.Author
+}
+
 // abortOnError 遇到错误时终止程序并记录错误日志
 // 参数:
 //
@@ -84,10 +149,13 @@ func logError(err error) {
 }
 
 // Register 根据配置注册计划任务
-// 每小时执行一次对应的更新检查命令
+// 每小时执行一次对应的更新检查命令，未选择任何任务类型时报错退出
 func Register() {
 	// 从命令行参数加载注册配置
 	reg := LoadRegistration(os.Args[2:]...)
+	if !reg.Any() {
+		abortOnError(ErrNoTaskSelected)
+	}
 	exe, _ := os.Executable()
 
 	// 根据配置注册不同的计划任务
@@ -105,10 +173,13 @@ func Register() {
 	}
 }
 
-// Unregister 根据配置注销计划任务
+// Unregister 根据配置注销计划任务，未选择任何任务类型时报错退出
 func Unregister() {
 	// 从命令行参数加载注册配置
 	reg := LoadRegistration(os.Args[2:]...)
+	if !reg.Any() {
+		abortOnError(ErrNoTaskSelected)
+	}
 
 	// 根据配置注销不同的计划任务
 	if reg.LTS {
@@ -138,6 +209,36 @@ func Unregister() {
 //	error: 创建任务过程中遇到的错误
 func ScheduleTask(name string, command string, interval string, startTime ...string) error {
 	// 验证间隔参数有效性
+	interval, err := validateInterval(interval)
+	if err != nil {
+		return err
+	}
+
+	// 设置默认开始时间
+	start := "00:00"
+	if len(startTime) > 0 {
+		start = startTime[0]
+	}
+
+	// 直接调用schtasks，暂时性错误时自动重试
+	out, err := runSchtasks("/create", "/tn", name, "/tr", "cmd.exe /c "+command, "/sc", strings.ToLower(interval), "/st", start, "/F")
+	if err != nil {
+		return fmt.Errorf("scheduling error: %v\n%s", err, out)
+	}
+
+	return nil
+}
+
+// validateInterval 验证并规范化计划任务执行间隔(内部函数)
+// 参数:
+//
+//	interval: 执行间隔
+//
+// 返回值:
+//
+//	string: 大写的执行间隔
+//	error: 执行间隔无效时返回的错误
+func validateInterval(interval string) (string, error) {
 	switch strings.ToUpper(interval) {
 	case "MINUTE":
 		fallthrough
@@ -158,53 +259,160 @@ func ScheduleTask(name string, command string, interval string, startTime ...str
 	case "ONIDLE":
 		fallthrough
 	case "EVENT":
-		interval = strings.ToUpper(interval)
-	default:
-		return fmt.Errorf("scheduling error: invalid interval %q", interval)
+		return strings.ToUpper(interval), nil
 	}
+	return "", fmt.Errorf("scheduling error: invalid interval %q", interval)
+}
 
-	// 设置默认开始时间
-	start := "00:00"
-	if len(startTime) > 0 {
-		start = startTime[0]
+// RescheduleTask 修改已注册计划任务的执行频率，无需先注销再注册
+// schtasks /change 不支持修改计划类型，因此读取任务原有的命令后使用 /create /F 原地替换
+// 参数:
+//
+//	name: 任务名称
+//	interval: 新的执行间隔(取值同ScheduleTask)
+//	startTime: 可选，任务开始时间，格式为"HH:MM"
+//
+// 返回值:
+//
+//	error: 任务未注册时返回ErrTaskNotFound，其他错误返回对应信息
+func RescheduleTask(name string, interval string, startTime ...string) error {
+	if _, err := validateInterval(interval); err != nil {
+		return err
 	}
 
-	// 创建临时目录存放批处理脚本
-	tmp, err := os.MkdirTemp("", "nvm4w-regitration-*")
+	command, err := taskCommand(name)
 	if err != nil {
-		return fmt.Errorf("scheduling error: %v", err)
-	}
-	defer os.RemoveAll(tmp)
-
-	// 生成创建计划任务的批处理脚本
-	script := fmt.Sprintf(`
-@echo off
-set errorlog="error.log"
-set output="%s\output.log"
-schtasks /create /tn "%s" /tr "cmd.exe /c %s" /sc %s /st %s /F > %%output%% 2>&1
-if not errorlevel 0 (
-	echo ERROR: Failed to create scheduled task: exit code: %%errorlevel%% >> %%errorlog%%
-	type %%output%% >> %%errorlog%%
-	exit /b %%errorlevel%%
-)
-	`, tmp, name, escapeBackslashes(command), strings.ToLower(interval), start)
+		return err
+	}
+
+	return ScheduleTask(name, command, interval, startTime...)
+}
 
-	// 写入批处理文件
-	err = os.WriteFile(filepath.Join(tmp, "schedule.bat"), []byte(script), os.ModePerm)
+// taskCommand 读取计划任务执行的命令(内部函数)
+// 参数:
+//
+//	name: 任务名称
+//
+// 返回值:
+//
+//	string: 注册时传给ScheduleTask的命令
+//	error: 任务未注册时返回ErrTaskNotFound
+func taskCommand(name string) (string, error) {
+	out, err := runSchtasks("/query", "/tn", name, "/xml")
 	if err != nil {
-		return fmt.Errorf("scheduling error: %v", err)
+		// 只有明确的"任务不存在"才返回ErrTaskNotFound，拒绝访问等错误需要报告给调用方
+		if code, _ := taskErrorCode(err, out); code == windows.ERROR_FILE_NOT_FOUND {
+			return "", fmt.Errorf("%w: %s", ErrTaskNotFound, name)
+		}
+		return "", fmt.Errorf("scheduling error: failed to read task %q: %v\n%s", name, err, strings.TrimSpace(out))
+	}
+
+	var task struct {
+		Exec []struct {
+			Command   string `xml:"Command"`
+			Arguments string `xml:"Arguments"`
+		} `xml:"Actions>Exec"`
+	}
+	decoder := xml.NewDecoder(strings.NewReader(out))
+	// 输出已解码为UTF-8，忽略XML声明中的UTF-16编码
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	if err := decoder.Decode(&task); err != nil {
+		return "", fmt.Errorf("scheduling error: failed to read task %q: %v", name, err)
+	}
+	if len(task.Exec) == 0 {
+		return "", fmt.Errorf("scheduling error: task %q has no command", name)
 	}
 
-	// 执行批处理文件
-	cmd := exec.Command(filepath.Join(tmp, "schedule.bat"))
+	// ScheduleTask 以 "cmd.exe /c <command>" 的形式注册，旧版本通过批处理注册的命令中反斜杠被转义
+	command := strings.TrimSpace(task.Exec[0].Arguments)
+	if strings.EqualFold(filepath.Base(task.Exec[0].Command), "cmd.exe") && strings.HasPrefix(strings.ToLower(command), "/c ") {
+		command = strings.TrimSpace(command[3:])
+	} else {
+		command = strings.TrimSpace(task.Exec[0].Command + " " + command)
+	}
+	return strings.ReplaceAll(command, "\\\\", "\\"), nil
+}
 
-	// 捕获标准输出和标准错误
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("scheduling error: %v\n%s", err, out)
+// TaskIssue 表示命令不再指向当前nvm.exe的计划任务
+type TaskIssue struct {
+	Task     string // 任务名称
+	Command  string // 任务当前执行的命令
+	Expected string // 当前nvm.exe的路径
+	Problem  string // 问题描述
+}
+
+// VerifyTaskCommands 检查已注册的更新检查计划任务是否指向当前的nvm.exe
+// nvm被移动或重新安装到其他位置后，旧任务仍会调用原来的路径，更新检查会在没有任何提示的情况下停止工作
+// 未注册的任务会被忽略，无法读取的任务也会作为问题报告，发现问题的任务需要重新注册
+// 参数:
+//
+//	installDir: nvm安装目录
+//
+// 返回值:
+//
+//	[]TaskIssue: 有问题的任务(全部有效时返回空切片)
+//	error: 所有任务都无法读取(如schtasks不可用或没有访问权限)时返回的错误，单个任务无法读取时通过TaskIssue报告
+func VerifyTaskCommands(installDir string) ([]TaskIssue, error) {
+	expected := filepath.Join(installDir, "nvm.exe")
+	issues := []TaskIssue{}
+	tasks := []string{NODE_LTS_SCHEDULE_NAME, NODE_CURRENT_SCHEDULE_NAME, NVM4W_SCHEDULE_NAME, AUTHOR_SCHEDULE_NAME}
+	unreadable := 0
+	var lastErr error
+
+	for _, name := range tasks {
+		command, err := taskCommand(name)
+		if errors.Is(err, ErrTaskNotFound) {
+			continue
+		}
+
+		issue := TaskIssue{Task: name, Command: command, Expected: expected}
+		if err != nil {
+			// 无法读取的任务同样可能已经失效，不能当作未注册跳过
+			issue.Problem = fmt.Sprintf("cannot be read: %v", err)
+			utility.DebugLogf("unreadable scheduled task %q: %v", name, err)
+			issues = append(issues, issue)
+			unreadable++
+			lastErr = err
+			continue
+		}
+		exe := commandExecutable(command)
+		if !strings.EqualFold(filepath.Clean(exe), filepath.Clean(expected)) {
+			issue.Problem = fmt.Sprintf("runs %s instead of %s", exe, expected)
+		} else if !strings.Contains(strings.ToLower(command), "checkforupdates") {
+			issue.Problem = "does not run an update check"
+		} else {
+			continue
+		}
+		utility.DebugLogf("stale scheduled task %q: %s", name, issue.Problem)
+		issues = append(issues, issue)
 	}
 
-	return nil
+	if unreadable == len(tasks) {
+		return issues, fmt.Errorf("cannot read any scheduled task: %w", lastErr)
+	}
+	return issues, nil
+}
+
+// commandExecutable 获取命令行中的可执行文件路径(内部函数)
+// 参数:
+//
+//	command: 命令行(可执行文件路径可带引号)
+//
+// 返回值: 可执行文件路径
+func commandExecutable(command string) string {
+	command = strings.TrimSpace(command)
+	if strings.HasPrefix(command, `"`) {
+		if end := strings.Index(command[1:], `"`); end >= 0 {
+			return command[1 : end+1]
+		}
+		return strings.Trim(command, `"`)
+	}
+	if fields := strings.Fields(command); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
 }
 
 // UnscheduleTask 删除 Windows 计划任务
@@ -216,40 +424,228 @@ if not errorlevel 0 (
 //
 //	error: 删除任务过程中遇到的错误
 func UnscheduleTask(name string) error {
-	// 创建临时目录存放批处理脚本
-	tmp, err := os.MkdirTemp("", "nvm4w-registration-*")
+	// 直接调用schtasks，暂时性错误时自动重试
+	out, err := runSchtasks("/delete", "/tn", name, "/f")
 	if err != nil {
-		return fmt.Errorf("scheduling error: %v", err)
-	}
-	defer os.RemoveAll(tmp)
-
-	// 生成删除计划任务的批处理脚本
-	script := fmt.Sprintf(`
-@echo off
-set errorlog="error.log"
-set output="%s\output.log"
-schtasks /delete /tn "%s" /f > %%output%% 2>&1
-if not errorlevel 0 (
-	echo failed to remove scheduled task: exit code: %%errorlevel%% >> %%errorlog%%
-	type %%output%% >> %%errorlog%%
-	exit /b %%errorlevel%%
-)
-	`, tmp, name)
+		return fmt.Errorf("unscheduling error: %v\n%s", err, out)
+	}
 
-	// 写入批处理文件
-	err = os.WriteFile(filepath.Join(tmp, "unschedule.bat"), []byte(script), os.ModePerm)
-	if err != nil {
-		return fmt.Errorf("unscheduling error: %v", err)
+	return nil
+}
+
+// runSchtasks 执行schtasks命令，遇到暂时性错误(如任务计划程序服务繁忙)时重试(内部函数)
+// 拒绝访问、参数无效、任务不存在等永久性错误不会重试
+// 参数:
+//
+//	args: schtasks命令行参数
+//
+// 返回值:
+//
+//	string: 最后一次执行的输出(已按控制台编码解码)
+//	error: 所有尝试均失败或遇到永久性错误时返回最后一次的错误
+func runSchtasks(args ...string) (string, error) {
+	var out string
+	var err error
+	for i := 1; i <= SCHTASKS_ATTEMPTS; i++ {
+		var raw []byte
+		raw, err = exec.Command("schtasks", args...).CombinedOutput()
+		out = encoding.DecodeOutput(raw)
+		if err == nil || !transientTaskError(err, out) {
+			return out, err
+		}
+		if i < SCHTASKS_ATTEMPTS {
+			utility.DebugLogf("schtasks failed (attempt %d of %d), retrying: %v", i, SCHTASKS_ATTEMPTS, err)
+			time.Sleep(SCHTASKS_RETRY_DELAY)
+		}
+	}
+	return out, err
+}
+
+// transientTaskError 检查schtasks错误是否可能通过重试解决(内部函数)
+// 参数:
+//
+//	err: 执行错误
+//	out: schtasks的输出
+//
+// 返回值: 错误为暂时性错误时返回true
+func transientTaskError(err error, out string) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		// 无法启动schtasks等错误重试也不会成功
+		return false
+	}
+	_, permanent := taskErrorCode(err, out)
+	return !permanent
+}
+
+// taskErrorCode 识别schtasks失败对应的系统错误码(内部函数)
+// 退出码本身是错误码或HRESULT时直接使用，否则在输出中查找permanentTaskErrors的本地化消息
+// 参数:
+//
+//	err: 执行错误
+//	out: schtasks的输出
+//
+// 返回值:
+//
+//	windows.Errno: 识别出的错误码
+//	bool: 是否识别为permanentTaskErrors中的错误
+func taskErrorCode(err error, out string) (windows.Errno, bool) {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return 0, false
 	}
 
-	// 执行批处理文件
-	cmd := exec.Command(filepath.Join(tmp, "unschedule.bat"))
+	code := uint32(exitErr.ExitCode())
+	// HRESULT_FROM_WIN32: 0x8007xxxx
+	if code&0xFFFF0000 == 0x80070000 {
+		code &= 0xFFFF
+	}
+	output := strings.ToLower(out)
+	for _, errno := range permanentTaskErrors {
+		if code == uint32(errno) {
+			return errno, true
+		}
+		if msg := systemMessage(errno); msg != "" && strings.Contains(output, msg) {
+			return errno, true
+		}
+	}
+	return 0, false
+}
+
+// systemMessage 获取系统错误码在当前界面语言下的消息文本(内部函数)
+// 参数:
+//
+//	errno: 系统错误码
+//
+// 返回值: 小写并去掉首尾空白和句点的消息文本，获取失败时返回空字符串
+func systemMessage(errno windows.Errno) string {
+	buf := make([]uint16, 512)
+	n, err := windows.FormatMessage(windows.FORMAT_MESSAGE_FROM_SYSTEM|windows.FORMAT_MESSAGE_IGNORE_INSERTS, 0, uint32(errno), 0, buf, nil)
+	if err != nil || n == 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimRight(strings.TrimSpace(windows.UTF16ToString(buf[:n])), ".。"))
+}
 
-	// 捕获标准输出和标准错误
-	out, err := cmd.CombinedOutput()
+// NextRun 查询计划任务的下次运行时间
+// 参数:
+//
+//	name: 任务名称
+//
+// 返回值:
+//
+//	time.Time: 下次运行时间(本地时区)
+//	error: 任务未注册时返回ErrTaskNotFound，无法解析时返回错误
+func NextRun(name string) (time.Time, error) {
+	// CSV格式的列顺序固定为: 任务名称, 下次运行时间, 状态
+	out, err := runSchtasks("/query", "/tn", name, "/fo", "CSV", "/nh")
 	if err != nil {
-		return fmt.Errorf("unscheduling error: %v\n%s", err, out)
+		if code, _ := taskErrorCode(err, out); code == windows.ERROR_FILE_NOT_FOUND {
+			return time.Time{}, fmt.Errorf("%w: %s", ErrTaskNotFound, name)
+		}
+		return time.Time{}, fmt.Errorf("scheduling error: %v\n%s", err, out)
 	}
 
-	return nil
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil || len(records) == 0 || len(records[0]) < 2 {
+		return time.Time{}, fmt.Errorf("scheduling error: unexpected schtasks output %q", strings.TrimSpace(out))
+	}
+
+	return parseNextRunTime(records[0][1])
+}
+
+// parseNextRunTime 解析schtasks输出的下次运行时间(内部函数)
+// schtasks按当前用户的短日期和时间格式输出，因此优先使用区域设置生成的格式解析
+// 参数:
+//
+//	value: 下次运行时间字符串
+//
+// 返回值:
+//
+//	time.Time: 解析后的时间(本地时区)
+//	error: 任务没有计划的下次运行时间或格式无法识别时返回错误
+func parseNextRunTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "N/A") {
+		return time.Time{}, errors.New("scheduling error: task has no upcoming run")
+	}
+
+	layouts := nextRunLayouts
+	dateFormat, timeFormat := localeInfo(localeShortDate), localeInfo(localeTimeFormat)
+	if dateFormat != "" && timeFormat != "" {
+		layouts = append([]string{localeLayout(dateFormat) + " " + localeLayout(timeFormat)}, layouts...)
+		// Go只能解析"AM"/"PM"，先替换本地化的上午/下午标识
+		if am := localeInfo(localeAM); am != "" {
+			value = strings.Replace(value, am, "AM", 1)
+		}
+		if pm := localeInfo(localePM); pm != "" {
+			value = strings.Replace(value, pm, "PM", 1)
+		}
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("scheduling error: unrecognized next run time %q", value)
+}
+
+// localeLayout 将Windows区域设置中的日期时间格式(如"dd.MM.yyyy"、"h:mm:ss tt")转换为Go时间格式(内部函数)
+// 单引号中的内容按原样保留
+// 参数:
+//
+//	format: Windows日期时间格式
+//
+// 返回值: Go时间格式
+func localeLayout(format string) string {
+	var layout strings.Builder
+	for i := 0; i < len(format); {
+		if format[i] == '\'' {
+			end := strings.IndexByte(format[i+1:], '\'')
+			if end < 0 {
+				layout.WriteString(format[i+1:])
+				break
+			}
+			layout.WriteString(format[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+
+		matched := false
+		for _, t := range localeTokens {
+			if strings.HasPrefix(format[i:], t.token) {
+				layout.WriteString(t.layout)
+				i += len(t.token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			layout.WriteByte(format[i])
+			i++
+		}
+	}
+	return layout.String()
+}
+
+// localeInfo 读取当前用户的区域设置信息(内部函数)
+// 参数:
+//
+//	lctype: 信息类型(如localeShortDate)
+//
+// 返回值: 信息内容，读取失败时返回空字符串
+func localeInfo(lctype uint32) string {
+	proc := windows.NewLazySystemDLL("kernel32.dll").NewProc("GetLocaleInfoEx")
+	if proc.Find() != nil {
+		return ""
+	}
+	buf := make([]uint16, 128)
+	// 区域名称为nil表示LOCALE_NAME_USER_DEFAULT
+	n, _, _ := proc.Call(0, uintptr(lctype), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if n == 0 {
+		return ""
+	}
+	return windows.UTF16ToString(buf[:n])
 }
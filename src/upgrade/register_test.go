@@ -0,0 +1,48 @@
+package upgrade
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocaleLayout(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"M/d/yyyy h:mm:ss tt", "1/2/2006 3:04:05 PM"},
+		{"dd/MM/yyyy HH:mm:ss", "02/01/2006 15:04:05"},
+		{"dd.MM.yyyy H:mm:ss", "02.01.2006 15:04:05"},
+		{"yyyy/M/d tt h:mm:ss", "2006/1/2 PM 3:04:05"},
+		{"yyyy'年'M'月'd'日'", "2006年1月2日"},
+		{"d-MMM-yy", "2-Jan-06"},
+	}
+	for _, tt := range tests {
+		if got := localeLayout(tt.format); got != tt.want {
+			t.Errorf("localeLayout(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestLocaleLayoutDayMonthOrder(t *testing.T) {
+	// The same text is a different day depending on the locale's short date format
+	const value = "03/04/2026 10:15:00"
+	tests := []struct {
+		format string
+		month  time.Month
+		day    int
+	}{
+		{"MM/dd/yyyy HH:mm:ss", time.March, 4},
+		{"dd/MM/yyyy HH:mm:ss", time.April, 3},
+	}
+	for _, tt := range tests {
+		got, err := time.ParseInLocation(localeLayout(tt.format), value, time.Local)
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		if got.Month() != tt.month || got.Day() != tt.day {
+			t.Errorf("%s: parsed %q as %s, want %s %d", tt.format, value, got.Format("2006-01-02"), tt.month, tt.day)
+		}
+	}
+}
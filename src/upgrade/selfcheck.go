@@ -0,0 +1,81 @@
+package upgrade
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"nvm/arch"
+	"nvm/encoding"
+	"nvm/file"
+	"nvm/semver"
+)
+
+// selfCheckIcons 随安装包发布(见仓库assets目录)、通知和进度窗口使用的图标文件
+// 只列出实际发布的文件，resolveIcon中的其他别名(如checkmark.ico)仅作为可选的替代
+var selfCheckIcons = []string{"nvm.ico", "nodejs.ico", "download.ico", "success.ico", "alert.ico"}
+
+// SelfCheckError 自检发现的问题
+type SelfCheckError struct {
+	InstallDir string   // nvm安装目录
+	Problems   []string // 发现的问题(每个问题一条)
+}
+
+// Error 实现error接口
+func (e *SelfCheckError) Error() string {
+	return fmt.Sprintf("%d problem(s) found in %s:\n  - %s", len(e.Problems), e.InstallDir, strings.Join(e.Problems, "\n  - "))
+}
+
+// SelfCheck 检查nvm安装目录是否完整
+// 检查内容包括nvm.exe和author-nvm.exe是否存在且架构一致、所需图标文件是否存在，以及nvm.exe version能否运行并返回有效版本号
+// 参数:
+//
+//	installDir: nvm安装目录
+//
+// 返回值: 未发现问题时返回nil，否则返回列出所有问题的*SelfCheckError
+func SelfCheck(installDir string) error {
+	problems := []string{}
+	nvm := filepath.Join(installDir, "nvm.exe")
+	bridge := filepath.Join(installDir, "author-nvm.exe")
+
+	nvmArch := ""
+	for _, exe := range []string{nvm, bridge} {
+		if !file.Exists(exe) {
+			problems = append(problems, fmt.Sprintf("%s is missing", filepath.Base(exe)))
+			continue
+		}
+		bit := arch.Bit(exe)
+		if bit == "?" {
+			problems = append(problems, fmt.Sprintf("%s is not a recognizable executable (it may be corrupt)", filepath.Base(exe)))
+			continue
+		}
+		if nvmArch == "" {
+			nvmArch = bit
+		} else if bit != nvmArch {
+			problems = append(problems, fmt.Sprintf("%s is %s-bit, but nvm.exe is %s-bit", filepath.Base(exe), bit, nvmArch))
+		}
+	}
+
+	for _, icon := range selfCheckIcons {
+		if !file.Exists(filepath.Join(installDir, icon)) {
+			problems = append(problems, fmt.Sprintf("%s is missing", icon))
+		}
+	}
+
+	if file.Exists(nvm) {
+		out, err := exec.Command(nvm, "version").Output()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("nvm.exe version failed: %v", err))
+		} else if v := strings.TrimSpace(encoding.DecodeOutput(out)); v == "" {
+			problems = append(problems, "nvm.exe version returned no output")
+		} else if _, err := semver.New(strings.TrimPrefix(v, "v")); err != nil {
+			problems = append(problems, fmt.Sprintf("nvm.exe version returned an invalid version %q", v))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &SelfCheckError{InstallDir: installDir, Problems: problems}
+	}
+	return nil
+}
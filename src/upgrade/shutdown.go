@@ -0,0 +1,35 @@
+package upgrade
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// 升级过程中的取消请求(1为已请求取消)，通过atomic读写以便信号处理goroutine并发访问
+var cancelRequested int32 = 0
+
+// critical 在覆盖安装目录等不可中断的步骤中持有，收到中断信号时等待其释放后再退出
+var critical sync.Mutex
+
+// beginCritical 进入不可中断的步骤(内部函数)
+// 返回值: 已请求取消时返回false，此时不应开始该步骤
+func beginCritical() bool {
+	critical.Lock()
+	if atomic.LoadInt32(&cancelRequested) == 1 {
+		critical.Unlock()
+		return false
+	}
+	return true
+}
+
+// endCritical 离开不可中断的步骤(内部函数)
+func endCritical() {
+	critical.Unlock()
+}
+
+// requestCancel 请求取消升级，并等待正在进行的不可中断步骤完成(内部函数)
+// 返回后不会再开始新的不可中断步骤，调用方可以安全退出
+func requestCancel() {
+	atomic.StoreInt32(&cancelRequested, 1)
+	critical.Lock()
+}
@@ -0,0 +1,140 @@
+package upgrade
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// VERIFY_SIGNATURE_ENV 启用发布包签名验证的环境变量(设置为"1"或"true"时启用)
+	VERIFY_SIGNATURE_ENV = "NVM_VERIFY_SIGNATURE"
+
+	// SIGNATURE_ASSET 发布包对应的分离签名资源名称
+	SIGNATURE_ASSET = "nvm-noinstall.zip.sig"
+)
+
+// ErrSignatureMissing 启用了签名验证，但发布版本没有提供签名文件
+var ErrSignatureMissing = errors.New("signature verification error: the release does not provide a signature")
+
+// embeddedPublicKey 随源码嵌入的发布包签名公钥(signing.pub，格式与签名文件相同)
+// 源码中的signing.pub为空，发布构建需写入发布签名公钥或通过SigningPublicKey注入，否则启用签名验证时升级会被拒绝
+//
+//go:embed signing.pub
+var embeddedPublicKey string
+
+// SigningPublicKey 用于验证发布包签名的Ed25519公钥(base64编码)
+// 为空时使用嵌入的signing.pub，发布构建时通过 -ldflags "-X nvm/upgrade.SigningPublicKey=..." 注入
+var SigningPublicKey = ""
+
+// SignatureVerificationEnabled 检查是否通过环境变量启用了签名验证
+// 返回值: 是否启用签名验证
+func SignatureVerificationEnabled() bool {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv(VERIFY_SIGNATURE_ENV)))
+	return value == "1" || value == "true"
+}
+
+// VerifySignature 使用嵌入的公钥验证文件的分离签名
+// 参数:
+//
+//	assetPath: 待验证的文件路径
+//	sigPath: 签名文件路径(base64编码的Ed25519签名，允许以"untrusted comment:"开头的注释行)
+//
+// 返回值: 验证失败时返回错误
+func VerifySignature(assetPath, sigPath string) error {
+	encoded := SigningPublicKey
+	if encoded == "" {
+		encoded = firstDataLine(embeddedPublicKey)
+	}
+	if encoded == "" {
+		return errors.New("signature verification error: no public key is embedded in this build")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("signature verification error: embedded public key is invalid")
+	}
+
+	signature, err := readSignature(sigPath)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(assetPath)
+	if err != nil {
+		return fmt.Errorf("signature verification error: %v", err)
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(key), content, signature) {
+		return fmt.Errorf("signature verification failed for %s", assetPath)
+	}
+
+	return nil
+}
+
+// readSignature 从签名文件中读取签名数据(内部函数)
+// 参数:
+//
+//	sigPath: 签名文件路径
+//
+// 返回值:
+//
+//	[]byte: 解码后的签名
+//	error: 读取或解码过程中遇到的错误
+func readSignature(sigPath string) ([]byte, error) {
+	file, err := os.Open(sigPath)
+	if err != nil {
+		return nil, fmt.Errorf("signature verification error: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if isCommentLine(line) {
+			continue
+		}
+
+		signature, err := base64.StdEncoding.DecodeString(line)
+		if err != nil || len(signature) != ed25519.SignatureSize {
+			return nil, errors.New("signature verification error: signature file is malformed")
+		}
+		return signature, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("signature verification error: %v", err)
+	}
+
+	return nil, errors.New("signature verification error: signature file is empty")
+}
+
+// firstDataLine 返回文本中第一个非空、非注释的行(内部函数)
+// 参数:
+//
+//	text: 公钥或签名文件的内容
+//
+// 返回值: 去除首尾空白后的行内容，没有数据行时返回空字符串
+func firstDataLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !isCommentLine(line) {
+			return line
+		}
+	}
+	return ""
+}
+
+// isCommentLine 检查是否为签名或公钥文件中应跳过的空行或注释行(内部函数)
+// 参数:
+//
+//	line: 去除首尾空白后的行内容
+//
+// 返回值: 是否应跳过该行
+func isCommentLine(line string) bool {
+	return line == "" || strings.HasPrefix(strings.ToLower(line), "untrusted comment:")
+}
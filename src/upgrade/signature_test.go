@@ -0,0 +1,59 @@
+package upgrade
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedPublicKey(t *testing.T) {
+	encoded := firstDataLine(embeddedPublicKey)
+	if encoded == "" {
+		defer func(key string) { SigningPublicKey = key }(SigningPublicKey)
+		SigningPublicKey = ""
+		if err := VerifySignature("nvm-noinstall.zip", SIGNATURE_ASSET); err == nil || !strings.Contains(err.Error(), "no public key") {
+			t.Errorf("VerifySignature() without a public key = %v, want a missing key error", err)
+		}
+		return
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		t.Fatalf("signing.pub does not contain a valid Ed25519 public key: %v", err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(key string) { SigningPublicKey = key }(SigningPublicKey)
+	SigningPublicKey = base64.StdEncoding.EncodeToString(pub)
+
+	dir := t.TempDir()
+	asset := filepath.Join(dir, "nvm-noinstall.zip")
+	if err := os.WriteFile(asset, []byte("release archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	signature := "untrusted comment: test\n" + base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte("release archive"))) + "\n"
+	sig := filepath.Join(dir, SIGNATURE_ASSET)
+	if err := os.WriteFile(sig, []byte(signature), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifySignature(asset, sig); err != nil {
+		t.Errorf("VerifySignature() on a valid signature = %v", err)
+	}
+
+	if err := os.WriteFile(asset, []byte("tampered archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySignature(asset, sig); err == nil {
+		t.Error("VerifySignature() accepted a tampered archive")
+	}
+}
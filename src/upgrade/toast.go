@@ -0,0 +1,78 @@
+package upgrade
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+
+	"golang.org/x/sys/windows"
+)
+
+// toastScript 通过Windows Runtime显示Toast通知的PowerShell脚本模板
+// 使用单引号here-string，避免PowerShell展开通知内容中的变量
+var toastScript = template.Must(template.New("toast").Parse(`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+[Windows.UI.Notifications.ToastNotification, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+
+$template = @'
+<toast activationType="protocol" launch="{{.Link}}" duration="{{if .Duration}}{{.Duration}}{{else}}short{{end}}">
+    <visual>
+        <binding template="ToastGeneric">
+            {{if .Icon}}<image placement="appLogoOverride" src="{{.Icon}}" />{{end}}
+            <text><![CDATA[{{.Title}}]]></text>
+            <text><![CDATA[{{.Message}}]]></text>
+        </binding>
+    </visual>
+    {{if .Actions}}<actions>{{range .Actions}}
+        <action activationType="{{.Type}}" content="{{.Label}}" arguments="{{.URI}}" />{{end}}
+    </actions>{{end}}
+</toast>
+'@
+
+$xml = New-Object Windows.Data.Xml.Dom.XmlDocument
+$xml.LoadXml($template)
+$toast = New-Object Windows.UI.Notifications.ToastNotification $xml
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('{{.AppID}}').Show($toast)
+`))
+
+// ToastNotifier 通过PowerShell显示Windows桌面Toast通知
+// 是未通过SetNotifier设置后端(如author-nvm.exe桥接程序不存在)时的默认后端
+type ToastNotifier struct{}
+
+// Notify 显示一条桌面Toast通知，等待PowerShell执行完毕后返回
+// 参数:
+//
+//	data: 通知内容(操作URI中的&需由调用方转义为&amp;)
+//
+// 返回值: 生成或执行脚本过程中遇到的错误
+func (ToastNotifier) Notify(data Notification) error {
+	// here-string以单独一行的'@结束，内容中不能出现该序列
+	data.Title = strings.ReplaceAll(data.Title, "\n'@", "\n '@")
+	data.Message = strings.ReplaceAll(data.Message, "\n'@", "\n '@")
+
+	var script bytes.Buffer
+	// PowerShell按UTF-8读取带BOM的脚本，否则非ASCII字符会乱码
+	script.WriteString("\ufeff")
+	if err := toastScript.Execute(&script, data); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp("", "nvm-toast-*.ps1")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(script.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", f.Name())
+	cmd.SysProcAttr = &windows.SysProcAttr{CreationFlags: windows.CREATE_NO_WINDOW}
+	return cmd.Run()
+}
@@ -0,0 +1,198 @@
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"nvm/arch"
+	"nvm/file"
+	"nvm/semver"
+	"nvm/utility"
+)
+
+// ErrStaleUpdateBinary .update目录中待替换的nvm.exe与本次升级的目标版本不一致
+var ErrStaleUpdateBinary = errors.New("stale update binary")
+
+// UpdateState 描述安装目录中.update目录的状态
+type UpdateState struct {
+	Path           string   // .update目录路径
+	Exists         bool     // .update目录是否存在
+	Binary         string   // 待替换的nvm.exe路径(不存在时为空)
+	BinaryValid    bool     // nvm.exe是否为可识别的可执行文件(下载或复制中断时可能不完整)
+	Updater        string   // update.exe路径(不存在时为空)
+	Backups        []Backup // 有效的升级备份(按时间从新到旧排列)
+	CorruptBackups []Backup // 无法读取的升级备份
+	Other          []string // 其他无法识别的文件或目录
+}
+
+// Clean 检查.update目录中是否没有遗留的升级文件
+// 返回值: 目录不存在，或只包含有效备份时返回true
+func (s UpdateState) Clean() bool {
+	return s.Binary == "" && s.Updater == "" && len(s.CorruptBackups) == 0 && len(s.Other) == 0
+}
+
+// InspectUpdateDir 检查安装目录中.update目录的内容
+// 升级失败或被中断后，目录中可能残留不完整的nvm.exe、update.exe或损坏的备份
+// 参数:
+//
+//	installDir: nvm安装目录
+//
+// 返回值:
+//
+//	UpdateState: .update目录的状态
+//	error: 读取目录过程中遇到的错误(目录不存在时不报错)
+func InspectUpdateDir(installDir string) (UpdateState, error) {
+	state := UpdateState{Path: filepath.Join(installDir, ".update")}
+
+	entries, err := os.ReadDir(state.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, fmt.Errorf("error inspecting update directory: %v", err)
+	}
+	state.Exists = true
+
+	backups, err := ListBackups(installDir)
+	if err != nil {
+		return state, err
+	}
+	known := map[string]bool{}
+	for _, b := range backups {
+		known[b.Name] = true
+		if file.VerifyZip(b.Path) == nil {
+			state.Backups = append(state.Backups, b)
+		} else {
+			state.CorruptBackups = append(state.CorruptBackups, b)
+		}
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(state.Path, name)
+		switch {
+		case known[name]:
+		case !entry.IsDir() && name == "nvm.exe":
+			state.Binary = path
+			state.BinaryValid = arch.Bit(path) != "?"
+		case !entry.IsDir() && name == "update.exe":
+			state.Updater = path
+		default:
+			state.Other = append(state.Other, path)
+		}
+	}
+
+	return state, nil
+}
+
+// CleanUpdateDir 重置.update目录，删除遗留的nvm.exe、update.exe、损坏的备份及其他文件
+// 有效的升级备份会被保留，目录因此变为空时将其删除，否则重新设置隐藏属性
+// 参数:
+//
+//	installDir: nvm安装目录
+//
+// 返回值:
+//
+//	[]string: 已删除的文件或目录
+//	error: 检查或删除过程中遇到的错误
+func CleanUpdateDir(installDir string) ([]string, error) {
+	state, err := InspectUpdateDir(installDir)
+	if err != nil || !state.Exists {
+		return []string{}, err
+	}
+
+	targets := append([]string{}, state.Other...)
+	if state.Binary != "" {
+		targets = append(targets, state.Binary)
+	}
+	if state.Updater != "" {
+		targets = append(targets, state.Updater)
+	}
+	for _, b := range state.CorruptBackups {
+		targets = append(targets, b.Path)
+	}
+
+	removed := []string{}
+	for _, target := range targets {
+		if err := os.RemoveAll(target); err != nil {
+			return removed, fmt.Errorf("error cleaning update directory: %v", err)
+		}
+		removed = append(removed, target)
+	}
+
+	if len(state.Backups) == 0 {
+		if err := os.Remove(state.Path); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("error cleaning update directory: %v", err)
+		}
+		return removed, nil
+	}
+
+	return removed, utility.SetHidden(state.Path, true)
+}
+
+// ValidateUpdateBinary 检查待替换的nvm.exe是否正是本次升级的目标版本
+// 之前被中断的升级可能遗留旧的.update/nvm.exe，替换前检查可避免把安装降级或替换成错误的版本
+// 参数:
+//
+//	path: 待替换的nvm.exe路径(通常为.update/nvm.exe)
+//	current: 当前安装的版本
+//	target: 本次升级的目标版本
+//
+// 返回值: 无法识别文件版本，或版本低于current、不等于target时返回包装ErrStaleUpdateBinary的错误
+func ValidateUpdateBinary(path, current, target string) error {
+	want, err := semver.New(target)
+	if err != nil {
+		return fmt.Errorf("invalid target version %q: %v", target, err)
+	}
+
+	raw, err := stagedVersion(path)
+	if err != nil {
+		return fmt.Errorf("%w: cannot determine the version of %s: %v", ErrStaleUpdateBinary, path, err)
+	}
+	staged, err := semver.New(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %s reports an invalid version %q", ErrStaleUpdateBinary, path, raw)
+	}
+
+	if installed, err := semver.New(current); err == nil && staged.LT(installed) {
+		utility.DebugLogf("refusing to apply %s: v%s is older than the installed v%s", path, staged, installed)
+		return fmt.Errorf("%w: %s is v%s, which is older than the installed v%s", ErrStaleUpdateBinary, path, staged, installed)
+	}
+	if staged.Compare(want) != 0 {
+		utility.DebugLogf("refusing to apply %s: v%s does not match the target v%s", path, staged, want)
+		return fmt.Errorf("%w: %s is v%s, expected v%s", ErrStaleUpdateBinary, path, staged, want)
+	}
+
+	utility.DebugLogf("%s matches the target version v%s", path, want)
+	return nil
+}
+
+// stagedVersion 获取nvm.exe的版本号(内部函数)
+// 优先读取版本资源(保留前三段，如"1.2.2.0" -> "1.2.2")，不存在时运行"nvm.exe version"
+// 参数:
+//
+//	path: nvm.exe路径
+//
+// 返回值:
+//
+//	string: 版本号
+//	error: 两种方式都无法获取版本时返回的错误
+func stagedVersion(path string) (string, error) {
+	if v, err := arch.FileVersion(path); err == nil {
+		parts := strings.Split(strings.TrimSpace(v), ".")
+		if len(parts) > 3 {
+			parts = parts[:3]
+		}
+		return strings.Join(parts, "."), nil
+	}
+
+	out, err := exec.Command(path, "version").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -9,22 +9,29 @@ package upgrade
 import (
 	"archive/zip"
 	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
-	"nvm/author"
+	"nvm/file"
+	"nvm/node"
 	"nvm/semver"
 	"nvm/utility"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
-	"unsafe"
 
 	"github.com/coreybutler/go-fsutil"
 	"github.com/ncruces/zenity"
@@ -48,15 +55,61 @@ const (
 	warningIcon = "⚠️" // 警告图标
 )
 
+// HTTPClient 升级包使用的HTTP客户端(可替换，便于测试)
+var HTTPClient = &http.Client{}
+
+// AlertsURL 当前使用的警告信息源地址(默认为ALERTS_URL，可替换，便于测试)
+var AlertsURL = ALERTS_URL
+
+// SetAlertsURL 设置警告信息源地址
+// 参数:
+//
+//	u: 信息源地址，为空时恢复ALERTS_URL
+func SetAlertsURL(u string) {
+	if u == "" {
+		u = ALERTS_URL
+	}
+	AlertsURL = u
+}
+
+// ErrEmptyResponse 服务器返回了成功状态，但响应内容为空或小于MinResponseSize
+var ErrEmptyResponse = errors.New("empty response")
+
+// MinResponseSize 下载内容的最小字节数，小于该值的响应视为损坏(默认为1，即只拒绝空响应)
+var MinResponseSize = 1
+
+// SetMinResponseSize 设置下载内容的最小字节数
+// 参数:
+//
+//	n: 最小字节数(小于1时按1处理)
+func SetMinResponseSize(n int) {
+	if n < 1 {
+		n = 1
+	}
+	MinResponseSize = n
+}
+
+// SetHTTPClient 设置升级包使用的HTTP客户端
+// 参数:
+//
+//	c: HTTP客户端，为nil时恢复默认客户端
+func SetHTTPClient(c *http.Client) {
+	if c == nil {
+		c = &http.Client{}
+	}
+	HTTPClient = c
+}
+
 // Notification 表示系统通知的结构体
 type Notification struct {
-	AppID    string   `json:"app_id"`   // 应用ID
-	Title    string   `json:"title"`    // 通知标题
-	Message  string   `json:"message"`  // 通知内容
-	Icon     string   `json:"icon"`     // 图标类型
-	Actions  []Action `json:"actions"`  // 可执行操作列表
-	Duration string   `json:"duration"` // 显示时长
-	Link     string   `json:"link"`     // 相关链接
+	AppID    string   `json:"app_id"`        // 应用ID
+	Title    string   `json:"title"`         // 通知标题
+	Message  string   `json:"message"`       // 通知内容
+	Icon     string   `json:"icon"`          // 图标类型
+	Actions  []Action `json:"actions"`       // 可执行操作列表
+	Duration string   `json:"duration"`      // 显示时长
+	Link     string   `json:"link"`          // 相关链接
+	Tag      string   `json:"tag,omitempty"` // 去重标识，相同标识的通知在NOTIFICATION_DEDUP_WINDOW内只显示一次
 }
 
 // Action 表示通知中的可执行操作
@@ -66,14 +119,21 @@ type Action struct {
 	URI   string `json:"uri"`   // 操作目标URI
 }
 
-// display 发送系统通知
+// display 通过当前配置的Notifier发送系统通知(见SetNotifier)
 // 参数:
 //
 //	data: 通知内容
 func display(data Notification) {
+	if data.Tag != "" && !markNotification(data.Tag, time.Now()) {
+		utility.DebugLogf("suppressing duplicate notification %q", data.Tag)
+		return
+	}
+
 	data.AppID = "NVM for Windows"
-	content, _ := json.Marshal(data)
-	go author.Bridge("notify", string(content))
+	data.Icon = resolveIcon(data.Icon)
+	if err := notifier.Notify(data); err != nil {
+		utility.DebugLogf("failed to send notification: %v", err)
+	}
 }
 
 // Update 表示可用的更新信息
@@ -83,6 +143,31 @@ type Update struct {
 	Warnings        []string `json:"notices"`        // 通用警告信息
 	VersionWarnings []string `json:"versionNotices"` // 版本特定警告
 	SourceURL       string   `json:"sourceTpl"`      // 更新包下载URL模板
+	SignatureURL    string   `json:"signatureUrl"`   // 更新包分离签名的下载URL(可能为空)
+	Size            int64    `json:"size"`           // 更新包大小(字节，未知时为0)
+	Security        bool     `json:"security"`       // 是否为安全更新(见IsSecurityUpdate)
+	BinaryURL       string   `json:"binaryUrl"`      // 单独发布的nvm.exe下载URL(可能为空)
+	ManifestURL     string   `json:"manifestUrl"`    // 升级包文件清单的下载URL(可能为空，见downloadBinary)
+
+	releaseAssets []ReleaseAsset // 发布中的所有资源(见ListReleaseAssets)
+}
+
+// ReleaseAsset 表示GitHub发布中的单个资源文件
+type ReleaseAsset struct {
+	Name        string `json:"name"`        // 文件名
+	Size        int64  `json:"size"`        // 文件大小(字节)
+	URL         string `json:"url"`         // 下载地址
+	ContentType string `json:"contentType"` // MIME类型
+}
+
+// ListReleaseAssets 获取发布中的所有资源文件(不限于升级流程使用的资源)
+// 返回值: 资源列表(没有资源时返回空切片)
+func (u *Update) ListReleaseAssets() []ReleaseAsset {
+	assets := []ReleaseAsset{}
+	if u != nil {
+		assets = append(assets, u.releaseAssets...)
+	}
+	return assets
 }
 
 // Release 表示GitHub发布的版本信息
@@ -97,17 +182,28 @@ type Release struct {
 //
 //	version: 当前版本号
 //
-// 返回值: 升级过程中遇到的错误
+// 返回值: 升级过程中遇到的错误(非进度UI模式下由调用方输出)
 // 功能:
 //   - 检查是否需要显示进度UI
 //   - 设置信号处理
 //   - 启动升级流程
 func Run(version string) error {
+	// Remove temporary directories left behind by interrupted upgrades
+	if removed, err := CleanupTempArtifacts(); err != nil {
+		utility.DebugLog(err.Error())
+	} else if len(removed) > 0 {
+		utility.DebugLogf("removed stale temporary directories: %v", removed)
+	}
+
+	attempt.from = version
+
 	show_progress := false
 	for _, arg := range os.Args[2:] {
-		if strings.ToLower(arg) == "--show-progress-ui" {
+		switch strings.ToLower(arg) {
+		case "--show-progress-ui":
 			show_progress = true
-			break
+		case "--skip-alerts":
+			SetSkipAlerts(true)
 		}
 	}
 
@@ -122,37 +218,52 @@ func Run(version string) error {
 		// Add signal handler
 		go func() {
 			<-signalChan
+			// Never exit while the install directory is being overwritten
+			fmt.Println("canceling, waiting for the current step to finish...")
+			requestCancel()
+			attempt.record(Canceled, nil)
 			fmt.Println("Installation canceled by user")
 			os.Exit(0)
 		}()
 
+		// Errors are only reported through run's return value, the channel carries progress
+		done := make(chan struct{})
 		go func() {
-			for {
-				select {
-				case s := <-status:
-					if s.Warn != "" {
-						Warn(s.Warn)
-					}
-					if s.Err != nil {
-						fmt.Println(s.Err)
-						os.Exit(1)
-					}
+			defer close(done)
+			for s := range status {
+				if s.Warn != "" {
+					Warn(s.Warn)
+				}
 
-					if s.Text != "" {
-						fmt.Println(s.Text)
-					}
+				if s.Text != "" {
+					fmt.Println(s.Text)
+				}
 
-					if s.Done {
+				if s.Done {
+					switch s.Result {
+					case AlreadyCurrent:
+						fmt.Println("nvm is up to date")
+					case Relaunched:
+						fmt.Println("administrator rights are required, the upgrade will continue in an elevated window")
+					default:
 						fmt.Println("Upgrade complete")
-						return
 					}
 				}
 			}
 		}()
 
-		time.Sleep(300 * time.Millisecond)
-
-		return run(version, status)
+		result, err := run(version, status)
+		if err == nil {
+			status <- Status{Done: true, Result: result}
+		}
+		close(status)
+		<-done
+		if err != nil {
+			attempt.record(Failed, err)
+		} else if result == AlreadyCurrent || result == Relaunched {
+			attempt.record(result, nil)
+		}
+		return err
 	}
 
 	wg := &sync.WaitGroup{}
@@ -177,6 +288,7 @@ func Run(version string) error {
 				}
 
 				if s.Cancel {
+					attempt.record(Canceled, nil)
 					display(Notification{
 						Title:   "Installation Canceled",
 						Message: fmt.Sprintf("Installation of NVM for Windows v%s was canceled by the user.", u.Version),
@@ -191,6 +303,7 @@ func Run(version string) error {
 
 				if s.Err != nil {
 					exitCode = 1
+					attempt.record(Failed, s.Err)
 					display(Notification{
 						Title:   "Installation Error",
 						Message: s.Err.Error(),
@@ -217,13 +330,29 @@ func Run(version string) error {
 				}
 
 				if s.Done {
-					display(Notification{
-						Title:   "Upgrade Complete",
-						Message: fmt.Sprintf("Now running version %s.", u.Version),
-						Icon:    "success",
-					})
-
-					dlg.Text("Upgrade complete")
+					switch s.Result {
+					case AlreadyCurrent:
+						attempt.record(AlreadyCurrent, nil)
+						display(Notification{
+							Title:   "No Upgrade Necessary",
+							Message: fmt.Sprintf("NVM for Windows v%s is already up to date.", version),
+							Icon:    "success",
+						})
+
+						dlg.Text("nvm is up to date")
+					case Relaunched:
+						// The elevated process reports its own result
+						attempt.record(Relaunched, nil)
+						dlg.Text("continuing in an elevated window")
+					default:
+						display(Notification{
+							Title:   "Upgrade Complete",
+							Message: fmt.Sprintf("Now running version %s.", u.Version),
+							Icon:    "success",
+						})
+
+						dlg.Text("Upgrade complete")
+					}
 					time.Sleep(1 * time.Second)
 
 					return
@@ -242,20 +371,17 @@ func Run(version string) error {
 
 	// Run the update
 	go func() {
-		exe, _ := os.Executable()
-		winIco := filepath.Join(filepath.Dir(exe), "nvm.ico")
-		ico := filepath.Join(filepath.Dir(exe), "download.ico")
+		winIco := resolveIcon("nvm")
+		ico := resolveIcon("download")
 
 		var err error
 		u, err = checkForUpdate(UPDATE_URL)
 		if err != nil {
-			display(Notification{
-				Title:   "Update Error",
-				Message: err.Error(),
-				Icon:    "error",
-			})
-
-			status <- Status{Err: fmt.Errorf("error: failed to obtain update data: %v\n", err)}
+			// Diagnostics must be on disk before the status goroutine reports the error and exits
+			err = fmt.Errorf("error: failed to obtain update data: %v\n", err)
+			writeFailureDiagnostics(err)
+			status <- Status{Err: err}
+			return
 		}
 
 		var perr error
@@ -283,7 +409,13 @@ func Run(version string) error {
 		}()
 		status <- Status{Text: "Validating version..."}
 
-		run(version, status, u)
+		// run writes the failure diagnostics before returning, so they exist before the error is reported
+		result, err := run(version, status, u)
+		if err != nil {
+			status <- Status{Err: err}
+		} else {
+			status <- Status{Done: true, Result: result}
+		}
 	}()
 
 	wg.Wait()
@@ -292,11 +424,16 @@ func Run(version string) error {
 	return nil
 }
 
-func run(version string, status chan Status, updateMetadata ...*Update) error {
+func run(version string, status chan Status, updateMetadata ...*Update) (result UpgradeResult, err error) {
+	defer func() {
+		if err != nil {
+			writeFailureDiagnostics(err)
+		}
+	}()
+
 	args := os.Args[2:]
-	colorize := true
 	if err := EnableVirtualTerminalProcessing(); err != nil {
-		colorize = false
+		utility.SetColorEnabled(false)
 	}
 
 	// Retrieve remote metadata
@@ -304,13 +441,15 @@ func run(version string, status chan Status, updateMetadata ...*Update) error {
 	if len(updateMetadata) > 0 {
 		update = updateMetadata[0]
 	} else {
-		var err error
 		update, err = checkForUpdate(UPDATE_URL)
 		if err != nil {
-			return fmt.Errorf("error: failed to obtain update data: %v\n", err)
+			return Failed, fmt.Errorf("error: failed to obtain update data: %v\n", err)
 		}
 	}
 
+	attempt.to = update.Version
+	attempt.update = update
+
 	for _, warning := range update.Warnings {
 		status <- Status{Warn: warning}
 	}
@@ -372,12 +511,12 @@ func run(version string, status chan Status, updateMetadata ...*Update) error {
 
 	currentVersion, err := semver.New(version)
 	if err != nil {
-		return err
+		return Failed, err
 	}
 
 	updateVersion, err := semver.New(update.Version)
 	if err != nil {
-		return err
+		return Failed, err
 	}
 
 	if currentVersion.LT(updateVersion) {
@@ -387,88 +526,48 @@ func run(version string, status chan Status, updateMetadata ...*Update) error {
 			}
 			for _, warning := range update.VersionWarnings {
 				status <- Status{Warn: warning}
-				Warn(warning, colorize)
 			}
 			fmt.Println("")
 		}
 		fmt.Printf("upgrading from v%s-->%s\n", version, highlight(update.Version))
 		status <- Status{Text: "downloading..."}
 	} else {
-		status <- Status{Text: "nvm is up to date", Done: true}
-		return nil
-	}
-
-	// Make temp directory
-	tmp, err := os.MkdirTemp("", "nvm-upgrade-*")
-	if err != nil {
-		status <- Status{Err: fmt.Errorf("error: failed to create temporary directory: %v\n", err)}
-	}
-	defer os.RemoveAll(tmp)
-
-	// Download the new app
-	source := update.SourceURL
-	// source := fmt.Sprintf(update.SourceURL, update.Version)
-	// source := fmt.Sprintf(update.SourceURL, "1.1.11") // testing
-	body, err := get(source)
-	if err != nil {
-		status <- Status{Err: fmt.Errorf("error: failed to download new version: %v\n", err)}
+		return AlreadyCurrent, nil
 	}
 
-	os.WriteFile(filepath.Join(tmp, "assets.zip"), body, os.ModePerm)
-	os.Mkdir(filepath.Join(tmp, "assets"), os.ModePerm)
-
-	source = source + ".checksum.txt"
-	body, err = get(source)
-	if err != nil {
-		return fmt.Errorf("error: failed to download checksum: %v\n", err)
+	// Make sure the install directory is writable before changing anything
+	currentPath := InstallDir()
+	if elevate, err := RequiresElevation(currentPath); err != nil {
+		utility.DebugLog(err.Error())
+	} else if elevate {
+		if IsElevated() {
+			return Failed, fmt.Errorf("error: cannot write to %s", currentPath)
+		}
+		proc, err := RelaunchElevated(os.Args[1:]...)
+		if err != nil {
+			return Failed, fmt.Errorf("error: administrator rights are required to upgrade %s: %v", currentPath, err)
+		}
+		utility.DebugLogf("relaunched elevated as PID %d", proc.Pid)
+		proc.Release()
+		return Relaunched, nil
 	}
 
-	os.WriteFile(filepath.Join(tmp, "assets.zip.checksum.txt"), body, os.ModePerm)
-
-	filePath := filepath.Join(tmp, "assets.zip")                  // path to the file you want to validate
-	checksumFile := filepath.Join(tmp, "assets.zip.checksum.txt") // path to the checksum file
-
-	// Step 1: Compute the MD5 checksum of the file
-	status <- Status{Text: "verifying checksum..."}
-	computedChecksum, err := computeMD5Checksum(filePath)
-	if err != nil {
-		status <- Status{Err: fmt.Errorf("Error computing checksum: %v", err)}
+	// Make sure there is enough room for the download, extraction and backup
+	if err := checkDiskSpace(update, currentPath); err != nil {
+		return Failed, err
 	}
 
-	// Step 2: Read the checksum from the .checksum.txt file
-	storedChecksum, err := readChecksumFromFile(checksumFile)
+	// Make temp directory
+	tmp, err := os.MkdirTemp("", "nvm-upgrade-*")
 	if err != nil {
-		status <- Status{Err: err}
+		return Failed, fmt.Errorf("error: failed to create temporary directory: %v\n", err)
 	}
+	defer os.RemoveAll(tmp)
 
-	// Step 3: Compare the computed checksum with the stored checksum
-	if strings.ToLower(computedChecksum) != strings.ToLower(storedChecksum) {
-		status <- Status{Err: fmt.Errorf("cannot validate update file (checksum mismatch)")}
-	}
-
-	status <- Status{Text: "extracting update..."}
-	if err := unzip(filepath.Join(tmp, "assets.zip"), filepath.Join(tmp, "assets")); err != nil {
-		status <- Status{Err: err}
-	}
-
-	// Get any additional assets
-	if len(update.Assets) > 0 {
-		status <- Status{Text: fmt.Sprintf("downloading %d additional assets...", len(update.Assets))}
-		for _, asset := range update.Assets {
-			var assetURL string
-			if !strings.HasPrefix(asset, "http") {
-				assetURL = update.SourceURL
-				// assetURL = fmt.Sprintf(update.SourceURL, asset)
-			} else {
-				assetURL = asset
-			}
-			assetBody, err := get(assetURL)
-			if err != nil {
-				status <- Status{Err: fmt.Errorf("error: failed to download asset: %v\n", err)}
-			}
-
-			assetPath := filepath.Join(tmp, "assets", asset)
-			os.WriteFile(assetPath, assetBody, os.ModePerm)
+	// When only nvm.exe changed, the standalone binary is downloaded instead of the full archive
+	if !downloadBinary(update, currentPath, tmp, version, status) {
+		if err := downloadArchive(update, tmp, status); err != nil {
+			return Failed, err
 		}
 	}
 
@@ -484,28 +583,49 @@ func run(version string, status chan Status, updateMetadata ...*Update) error {
 		}
 	}
 
+	// Make sure the downloaded binary is the target version before anything in the install directory changes
+	if err := ValidateUpdateBinary(filepath.Join(tmp, "assets", "nvm.exe"), version, update.Version); err != nil {
+		return Failed, err
+	}
+
 	// Backup current version to zip
 	status <- Status{Text: "applying update..."}
-	currentExe, _ := os.Executable()
-	currentPath := filepath.Dir(currentExe)
 	bkp, err := os.MkdirTemp("", "nvm-backup-*")
 	if err != nil {
-		status <- Status{Err: fmt.Errorf("error: failed to create backup directory: %v\n", err)}
+		return Failed, fmt.Errorf("error: failed to create backup directory: %v\n", err)
 	}
 	defer os.RemoveAll(bkp)
 
 	err = zipDirectory(currentPath, filepath.Join(bkp, "backup.zip"))
 	if err != nil {
-		status <- Status{Err: fmt.Errorf("error: failed to create backup: %v\n", err)}
+		return Failed, fmt.Errorf("error: failed to create backup: %v\n", err)
+	}
+
+	// Saving the backup, overwriting the install directory and swapping nvm.exe must not be interrupted
+	if !beginCritical() {
+		return Canceled, nil
 	}
+	defer endCritical()
 
-	os.MkdirAll(filepath.Join(currentPath, ".update"), os.ModePerm)
-	copyFile(filepath.Join(bkp, "backup.zip"), filepath.Join(currentPath, ".update", "nvm4w-backup.zip"))
+	SetBackupRetention(backupRetentionArg(args))
+	if err := saveBackup(filepath.Join(bkp, "backup.zip"), currentPath); err != nil {
+		return Failed, fmt.Errorf("error: failed to save backup: %v\n", err)
+	}
+
+	// The running nvm.exe is locked, so the new binary is staged in .update and swapped separately
+	stagedExe := filepath.Join(tmp, "assets", "nvm.exe")
+	if err := copyFile(stagedExe, filepath.Join(currentPath, ".update/nvm.exe")); err != nil {
+		return Failed, fmt.Errorf("error: failed to stage nvm.exe: %v\n", err)
+	}
+	if err := os.Remove(stagedExe); err != nil {
+		return Failed, fmt.Errorf("error: failed to stage nvm.exe: %v\n", err)
+	}
 
-	// Copy the new files to the current directory
+	// Copy the remaining new files to the current directory
 	// copyFile(currentExe, fmt.Sprintf("%s.%s.bak", currentExe, version))
-	copyDirContents(filepath.Join(tmp, "assets"), currentPath)
-	copyFile(filepath.Join(tmp, "assets", "nvm.exe"), filepath.Join(currentPath, ".update/nvm.exe"))
+	if err := copyDirContents(filepath.Join(tmp, "assets"), currentPath); err != nil {
+		return Failed, restoreAfterFailedUpdate(bkp, currentPath, fmt.Errorf("error: failed to copy the update files: %v\n", err))
+	}
 
 	if verbose {
 		nvmtestcmd := exec.Command(filepath.Join(currentPath, ".update/nvm.exe"), "version")
@@ -513,7 +633,7 @@ func run(version string, status chan Status, updateMetadata ...*Update) error {
 		nvmtestcmd.Stderr = os.Stderr
 		err = nvmtestcmd.Run()
 		if err != nil {
-			status <- Status{Err: err}
+			return Failed, err
 		}
 	}
 
@@ -522,31 +642,205 @@ func run(version string, status chan Status, updateMetadata ...*Update) error {
 		tree(currentPath, "final directory contents:")
 	}
 
-	// Hide the update directory
-	setHidden(filepath.Join(currentPath, ".update"))
+	// Hide the update directory (other attributes are preserved)
+	if err := utility.SetHidden(filepath.Join(currentPath, ".update"), true); err != nil {
+		utility.DebugLog(err.Error())
+	}
 
 	// If an "update.exe" exists, run it
 	if fsutil.IsExecutable(filepath.Join(tmp, "assets", "update.exe")) {
 		err = copyFile(filepath.Join(tmp, "assets", "update.exe"), filepath.Join(currentPath, ".update", "update.exe"))
 		if err != nil {
-			status <- Status{Err: fmt.Errorf("error: failed to copy update.exe: %v\n", err)}
-			os.Exit(1)
+			return Failed, fmt.Errorf("error: failed to copy update.exe: %v\n", err)
 		}
 	}
 
-	autoupdate(status)
+	// Make sure a binary left behind by an interrupted upgrade is never swapped in
+	if err := ValidateUpdateBinary(filepath.Join(currentPath, ".update", "nvm.exe"), version, update.Version); err != nil {
+		return Failed, restoreAfterFailedUpdate(bkp, currentPath, err)
+	}
+
+	// The running binary is locked, so it can only be swapped once this process exits
+	selfReplacing, err := IsSelfReplacing(currentPath)
+	if err != nil {
+		utility.DebugLog(err.Error())
+		selfReplacing = true
+	}
+	if selfReplacing {
+		if err := autoupdate(status); err != nil {
+			return Failed, err
+		}
+	} else if err := copyFile(filepath.Join(currentPath, ".update", "nvm.exe"), filepath.Join(currentPath, "nvm.exe")); err != nil {
+		return Failed, fmt.Errorf("error: failed to replace nvm.exe: %v\n", err)
+	} else {
+		attempt.record(Upgraded, nil)
+	}
+
+	return Upgraded, nil
+}
+
+// restoreAfterFailedUpdate 安装目录已被部分覆盖后升级失败时，从本次升级创建的备份恢复(内部函数)
+// 参数:
+//
+//	bkp: 保存backup.zip的临时目录
+//	installDir: nvm安装目录
+//	cause: 升级失败的原因
+//
+// 返回值: 升级失败的原因，恢复同样失败时一并包含恢复错误
+func restoreAfterFailedUpdate(bkp string, installDir string, cause error) error {
+	if err := restoreBackup(filepath.Join(bkp, "backup.zip"), installDir); err != nil {
+		return fmt.Errorf("%v\nthe previous version could not be restored: %v", strings.TrimSpace(cause.Error()), err)
+	}
+	utility.DebugLogf("restored %s from the backup after a failed update", installDir)
+	return cause
+}
+
+// downloadArchive 下载、校验并解压完整的升级包，以及发布中的附加资源(内部函数)
+// 参数:
+//
+//	update: 远程更新信息
+//	tmp: 临时目录(升级包解压到其中的assets目录)
+//	status: 升级状态通道
+//
+// 返回值: 下载或校验失败时返回的错误
+func downloadArchive(update *Update, tmp string, status chan Status) error {
+	// Download the checksum first so the archive can be verified while it is written
+	source := update.SourceURL
+	// source := fmt.Sprintf(update.SourceURL, update.Version)
+	// source := fmt.Sprintf(update.SourceURL, "1.1.11") // testing
+	body, err := get(source + ".checksum.txt")
+	if err != nil {
+		return fmt.Errorf("error: failed to download checksum: %v\n", err)
+	}
+
+	checksumFile := filepath.Join(tmp, "assets.zip.checksum.txt") // path to the checksum file
+	os.WriteFile(checksumFile, body, os.ModePerm)
+	expected, err := readChecksumFromFile(checksumFile, path.Base(source))
+	attempt.expected = expected
+	if err != nil {
+		return fmt.Errorf("error reading checksum: %v", err)
+	}
+
+	// Download the new app, hashing it as it is written (single pass)
+	filePath := filepath.Join(tmp, "assets.zip") // path to the downloaded archive
+	status <- Status{Text: "downloading and verifying checksum..."}
+	if err := Download(source, filePath, expected); err != nil {
+		var mismatch *ChecksumMismatchError
+		if errors.As(err, &mismatch) {
+			attempt.computed = mismatch.Computed
+		} else {
+			err = fmt.Errorf("error: failed to download new version: %v\n", err)
+		}
+		return err
+	}
+	os.Mkdir(filepath.Join(tmp, "assets"), os.ModePerm)
+
+	// Optionally verify the detached signature
+	if SignatureVerificationEnabled() {
+		if update.SignatureURL != "" {
+			status <- Status{Text: "verifying signature..."}
+			body, err = get(update.SignatureURL)
+			if err != nil {
+				return fmt.Errorf("error: failed to download signature: %v\n", err)
+			}
+
+			signatureFile := filepath.Join(tmp, SIGNATURE_ASSET)
+			if err := os.WriteFile(signatureFile, body, os.ModePerm); err != nil {
+				return fmt.Errorf("error: failed to save signature: %v\n", err)
+			}
+			if err := VerifySignature(filePath, signatureFile); err != nil {
+				return err
+			}
+		} else {
+			// 启用了验证却无法验证时必须中止，否则移除签名即可绕过验证
+			return ErrSignatureMissing
+		}
+	}
+
+	// unzip checks the archive structure itself before extracting anything
+	status <- Status{Text: "extracting update..."}
+	if err := unzip(filepath.Join(tmp, "assets.zip"), filepath.Join(tmp, "assets")); err != nil {
+		return err
+	}
+
+	// Get any additional assets
+	if len(update.Assets) > 0 {
+		status <- Status{Text: fmt.Sprintf("downloading %d additional assets...", len(update.Assets))}
+		for _, asset := range update.Assets {
+			var assetURL string
+			if !strings.HasPrefix(asset, "http") {
+				assetURL = update.SourceURL
+				// assetURL = fmt.Sprintf(update.SourceURL, asset)
+			} else {
+				assetURL = asset
+			}
+			assetBody, err := get(assetURL)
+			if err != nil {
+				return fmt.Errorf("error: failed to download asset: %v\n", err)
+			}
+
+			assetPath := filepath.Join(tmp, "assets", asset)
+			os.WriteFile(assetPath, assetBody, os.ModePerm)
+		}
+	}
 
 	return nil
 }
 
+// UpgradeResult 表示升级流程的最终结果
+type UpgradeResult int
+
+const (
+	Failed         UpgradeResult = iota // 升级失败
+	Upgraded                            // 已升级到新版本
+	AlreadyCurrent                      // 已是最新版本，无需升级
+	Canceled                            // 用户取消升级
+	Relaunched                          // 已以管理员权限重新启动，由新进程继续升级
+)
+
+// String 获取升级结果的文本表示
+// 返回值: 升级结果名称
+func (r UpgradeResult) String() string {
+	switch r {
+	case Upgraded:
+		return "upgraded"
+	case AlreadyCurrent:
+		return "up-to-date"
+	case Canceled:
+		return "canceled"
+	case Relaunched:
+		return "relaunched"
+	default:
+		return "failed"
+	}
+}
+
+// releaseAsset 将GitHub API返回的资源信息转换为ReleaseAsset，缺少或类型不符的字段保持零值(内部函数)
+// 参数:
+//
+//	asset: GitHub API返回的资源信息
+//
+// 返回值: 资源信息
+func releaseAsset(asset map[string]interface{}) ReleaseAsset {
+	a := ReleaseAsset{}
+	a.Name, _ = asset["name"].(string)
+	a.URL, _ = asset["browser_download_url"].(string)
+	a.ContentType, _ = asset["content_type"].(string)
+	if size, ok := asset["size"].(float64); ok {
+		a.Size = int64(size)
+	}
+	return a
+}
+
 // Status 表示升级过程中的状态信息
 type Status struct {
-	Text   string // 状态文本
-	Err    error  // 错误信息
-	Done   bool   // 是否完成
-	Help   bool   // 是否需要帮助
-	Cancel bool   // 是否取消
-	Warn   string // 警告信息
+	Text   string        // 状态文本
+	Err    error         // 错误信息(仅由Run在进度UI模式下发送，run及其辅助函数通过返回值报告错误)
+	Done   bool          // 是否完成(仅由Run在run返回后发送)
+	Result UpgradeResult // 升级结果(仅在Done为true时有效)
+	Help   bool          // 是否需要帮助
+	Cancel bool          // 是否取消
+	Warn   string        // 警告信息
 }
 
 func (u *Update) Available(sinceVersion string) (string, bool, error) {
@@ -571,9 +865,13 @@ func (u *Update) Available(sinceVersion string) (string, bool, error) {
 // 参数:
 //
 //	msg: 警告内容
-//	colorized: 是否使用颜色高亮
+//	colorized: 是否使用颜色高亮(可选，默认跟随utility.ColorEnabled，全局禁用颜色时始终不使用)
 func Warn(msg string, colorized ...bool) {
-	if len(colorized) > 0 && colorized[0] {
+	colorize := utility.ColorEnabled()
+	if len(colorized) > 0 {
+		colorize = colorize && colorized[0]
+	}
+	if colorize {
 		fmt.Println(warningIcon + "  " + highlight(msg))
 	} else {
 		fmt.Println(strings.ToUpper(msg))
@@ -589,16 +887,71 @@ func Get() (*Update, error) {
 	return checkForUpdate(UPDATE_URL)
 }
 
+// IsSelfReplacing 检查升级的目标nvm.exe是否就是当前正在运行的程序
+// Windows会锁定正在运行的可执行文件，此时只能通过批处理脚本在进程退出后替换，
+// 否则可以直接复制新文件
+// 参数:
+//
+//	installDir: 要升级的nvm安装目录
+//
+// 返回值:
+//
+//	bool: 目标nvm.exe与当前程序是同一个文件时返回true
+//	error: 无法获取当前程序路径时返回的错误
+func IsSelfReplacing(installDir string) (bool, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return false, fmt.Errorf("error getting the running executable: %v", err)
+	}
+	target, err := filepath.Abs(filepath.Join(installDir, "nvm.exe"))
+	if err != nil {
+		return false, err
+	}
+	return sameExecutable(exe, target), nil
+}
+
+// sameExecutable 检查两个路径是否指向同一个可执行文件(内部函数)
+// 参数:
+//
+//	a: 第一个文件路径
+//	b: 第二个文件路径
+//
+// 返回值: 解析符号链接后路径相同(不区分大小写)时返回true
+func sameExecutable(a, b string) bool {
+	// 解析符号链接，文件不存在时按原路径比较
+	if resolved, err := filepath.EvalSymlinks(a); err == nil {
+		a = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(b); err == nil {
+		b = resolved
+	}
+
+	// Windows路径不区分大小写
+	return strings.EqualFold(filepath.Clean(a), filepath.Clean(b))
+}
+
+// InstallDir 返回升级的目标安装目录
+// NVM_HOME中存在nvm.exe时使用NVM_HOME(安装程序设置的位置)，否则使用当前程序所在目录
+// 返回值: nvm安装目录
+func InstallDir() string {
+	if home := strings.TrimSpace(os.Getenv("NVM_HOME")); home != "" && fsutil.Exists(filepath.Join(home, "nvm.exe")) {
+		return filepath.Clean(home)
+	}
+	exe, _ := os.Executable()
+	return filepath.Dir(exe)
+}
+
 // autoupdate 自动执行更新流程(内部函数)
+// 更新脚本启动后当前进程退出，由脚本替换nvm.exe
 // 参数:
 //
 //	status: 状态通知通道
-func autoupdate(status chan Status) {
+//
+// 返回值: 无法启动更新脚本时返回的错误(成功时不会返回)
+func autoupdate(status chan Status) error {
 	currentPath, err := os.Executable()
 	if err != nil {
-		status <- Status{Err: err}
-		fmt.Println("error getting updater path:", err)
-		os.Exit(1)
+		return fmt.Errorf("error getting updater path: %v", err)
 	}
 
 	// Create temporary directory for the updater script
@@ -608,28 +961,26 @@ func autoupdate(status chan Status) {
 	// Temporary batch file that deletes the directory and the scheduled task
 	tmp, err := os.MkdirTemp("", "nvm4w-remove-*")
 	if err != nil {
-		status <- Status{Err: err}
-		fmt.Printf("error creating temporary directory: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("error creating temporary directory: %v", err)
 	}
 
-	// schedule removal of restoration folder for 30 days from now
+	// schedule removal of the staged binaries and the default backup for 7 days from now
+	// Timestamped backups kept through --keep-backups are left alone, they are pruned by saveBackup
 	tempBatchFile := filepath.Join(tmp, "remove_backup.bat")
 	now := time.Now()
 	futureDate := now.AddDate(0, 0, 7)
 	formattedDate := futureDate.Format("01/02/2006")
+	updateDir := escapeBackslashes(filepath.Join(filepath.Dir(currentPath), ".update"))
 	batchContent := fmt.Sprintf(`
 @echo off
 schtasks /delete /tn "RemoveNVM4WBackup" /f
-rmdir /s /q "%s"
-`, escapeBackslashes(filepath.Join(filepath.Dir(currentPath), ".update")))
+del /f /q "%[1]s\\nvm.exe" "%[1]s\\nvm.exe.old" "%[1]s\\update.exe" "%[1]s\\%[2]s" 2>nul
+`, updateDir, backupLegacyName)
 
 	// Write the batch file to a temporary location
 	err = os.WriteFile(tempBatchFile, []byte(batchContent), os.ModePerm)
 	if err != nil {
-		status <- Status{Err: err}
-		fmt.Printf("error creating temporary batch file: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("error creating temporary batch file: %v", err)
 	}
 
 	updaterScript := fmt.Sprintf(`@echo off
@@ -690,7 +1041,7 @@ if exist "%%~2" (
 :: Schedule the task to delete the directory
 echo schtasks /create /tn "RemoveNVM4WBackup" /tr "cmd.exe /c %s" /sc once /sd %s /st 12:00 /f >> error.log
 schtasks /create /tn "RemoveNVM4WBackup" /tr "cmd.exe /c %s" /sc once /sd %s /st 12:00 /f
-if not errorlevel 0 (
+if errorlevel 1 (
 	echo ERROR: Failed to create scheduled task: exit code: %%errorlevel%% >> error.log
 	exit /b %%errorlevel%%
 )
@@ -706,25 +1057,20 @@ exit /b 0
 
 	err = os.WriteFile(scriptPath, []byte(updaterScript), os.ModePerm) // Use standard Windows file permissions
 	if err != nil {
-		status <- Status{Err: err}
-		fmt.Printf("error creating updater script: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("error creating updater script: %v", err)
 	}
 
 	// Start the updater script
 	cmd := exec.Command(scriptPath, fmt.Sprintf("%d", os.Getpid()), filepath.Join(tempDir, ".update", "nvm.exe"), currentPath)
 	err = cmd.Start()
 	if err != nil {
-		status <- Status{Err: err}
-		fmt.Printf("error starting updater script: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("error starting updater script: %v", err)
 	}
 
-	// Exit the current process (delay for cleanup)
-	time.Sleep(300 * time.Millisecond)
-	status <- Status{Text: "restarting app...", Done: true}
-	time.Sleep(2 * time.Second)
-	os.Exit(0)
+	// The updater script replaces nvm.exe once Run has reported the result and the process exits
+	attempt.record(Upgraded, nil)
+	status <- Status{Text: "restarting app..."}
+	return nil
 }
 
 // escapeBackslashes 转义路径中的反斜杠(内部函数)
@@ -746,12 +1092,57 @@ func tree(dir string, title ...string) {
 	if len(title) > 0 {
 		fmt.Println("\n" + highlight(title[0]))
 	}
-	cmd := exec.Command("tree", dir, "/F")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		fmt.Println("Error executing command:", err)
+	lines, err := ListTree(dir)
+	if err != nil {
+		fmt.Println("Error listing directory:", err)
+		return
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
+
+// ListTree 遍历目录并返回缩进格式的目录树列表
+// 参数:
+//
+//	dir: 目录路径
+//
+// 返回值:
+//
+//	[]string: 目录树的每一行(首行为目录本身，子项按名称排序并按层级缩进)
+//	error: 遍历过程中遇到的错误
+func ListTree(dir string) ([]string, error) {
+	lines := []string{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		// 根目录直接输出完整路径
+		if relPath == "." {
+			lines = append(lines, path)
+			return nil
+		}
+
+		// WalkDir按字典序遍历，保证输出顺序稳定
+		depth := strings.Count(relPath, string(os.PathSeparator))
+		name := d.Name()
+		if d.IsDir() {
+			name += string(os.PathSeparator)
+		}
+		lines = append(lines, strings.Repeat("  ", depth+1)+name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	return lines, nil
 }
 
 // get 发送HTTP GET请求
@@ -765,30 +1156,169 @@ func tree(dir string, title ...string) {
 //	[]byte: 响应内容
 //	error: 请求过程中遇到的错误
 func get(url string, verbose ...bool) ([]byte, error) {
+	resp, err := open(url, verbose...)
+	if err != nil {
+		return []byte{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return body, err
+	}
+	if len(body) < MinResponseSize {
+		return body, fmt.Errorf("%w from %s: received %d bytes", ErrEmptyResponse, url, len(body))
+	}
+	return body, nil
+}
+
+// open 发送HTTP GET请求并返回状态为200的响应(内部函数)
+// 调用方负责关闭响应体
+// 参数:
+//
+//	url: 请求URL
+//	verbose: 是否显示详细日志
+//
+// 返回值:
+//
+//	*http.Response: 响应
+//	error: 请求失败或状态码不为200时返回的错误
+func open(url string, verbose ...bool) (*http.Response, error) {
 	if len(verbose) == 0 || verbose[0] {
 		fmt.Printf("  GET %s\n", url)
 	}
 
-	client := &http.Client{}
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 	req.Header.Set("User-Agent", "nvm-windows")
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Pragma", "no-cache")
 
-	resp, err := client.Do(req)
+	// Only send the token to the GitHub API, never to other hosts
+	token := githubToken()
+	authenticated := token != "" && req.URL.Host == "api.github.com"
+	if authenticated {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+
+	if err := rateLimitError(resp, authenticated); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
 
 	if resp.StatusCode != http.StatusOK {
-		return []byte{}, fmt.Errorf("error: received status code %d", resp.StatusCode)
+		resp.Body.Close()
+		return nil, fmt.Errorf("error: received status code %d", resp.StatusCode)
 	}
 
-	return io.ReadAll(resp.Body)
+	return resp, nil
+}
+
+// Download 下载文件并在写入磁盘的同时计算校验和，只需读取一遍数据即可完成校验
+// 校验和算法根据expected的长度自动识别(见detectHashAlgo)，校验失败时删除已写入的文件
+// 参数:
+//
+//	url: 文件下载地址
+//	target: 本地保存路径
+//	expected: 期望的校验和(十六进制)
+//
+// 返回值: 校验和不一致时返回*ChecksumMismatchError，其他问题返回对应错误
+func Download(url, target, expected string) error {
+	hasher, err := detectHashAlgo(expected)
+	if err != nil {
+		return err
+	}
+
+	resp, err := open(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	output, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+
+	written, err := io.Copy(io.MultiWriter(output, hasher), resp.Body)
+	if cerr := output.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && written < int64(MinResponseSize) {
+		err = fmt.Errorf("%w from %s: received %d bytes", ErrEmptyResponse, url, written)
+	}
+	if err != nil {
+		os.Remove(target)
+		return err
+	}
+
+	computed := hex.EncodeToString(hasher.Sum(nil))
+	if !strings.EqualFold(computed, strings.TrimSpace(expected)) {
+		os.Remove(target)
+		return &ChecksumMismatchError{Path: target, Expected: strings.ToLower(strings.TrimSpace(expected)), Computed: computed}
+	}
+
+	return nil
+}
+
+// githubToken 读取用于GitHub API认证的令牌(内部函数)
+// 返回值: GITHUB_TOKEN环境变量的值，未设置时返回空字符串
+func githubToken() string {
+	return strings.TrimSpace(os.Getenv("GITHUB_TOKEN"))
+}
+
+// RateLimitError 表示GitHub API速率限制错误
+type RateLimitError struct {
+	RetryAt       time.Time // 可重试的时间(未知时为零值)
+	Authenticated bool      // 请求是否已使用GITHUB_TOKEN认证
+}
+
+func (e *RateLimitError) Error() string {
+	msg := "error: GitHub API rate limit exceeded"
+	if !e.RetryAt.IsZero() {
+		msg += fmt.Sprintf(", try again after %s", e.RetryAt.Local().Format("2006-01-02 15:04:05"))
+	} else {
+		msg += ", try again later"
+	}
+	if e.Authenticated {
+		return msg + " (authenticated with GITHUB_TOKEN)"
+	}
+	return msg + " (unauthenticated requests are limited to 60 per hour, set GITHUB_TOKEN to raise the limit)"
+}
+
+// rateLimitError 检查响应是否为速率限制错误(内部函数)
+// 参数:
+//
+//	resp: HTTP响应
+//	authenticated: 请求是否已认证
+//
+// 返回值: 触发速率限制时返回*RateLimitError，否则返回nil
+func rateLimitError(resp *http.Response, authenticated bool) error {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	retryAfter := resp.Header.Get("Retry-After")
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if retryAfter == "" && remaining != "0" {
+		return nil
+	}
+
+	e := &RateLimitError{Authenticated: authenticated}
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		e.RetryAt = time.Now().Add(time.Duration(seconds) * time.Second)
+	} else if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		e.RetryAt = time.Unix(reset, 0)
+	}
+
+	return e
 }
 
 // checkForUpdate 检查是否有可用更新
@@ -804,10 +1334,18 @@ func checkForUpdate(url string) (*Update, error) {
 	u := Update{Assets: []string{}, Warnings: []string{}, VersionWarnings: []string{}}
 	r := Release{}
 
+	if node.IsOffline() {
+		return &u, node.ErrOffline
+	}
+
 	// Make the HTTP GET request
 	utility.DebugLogf("checking for updates at %s", url)
 	body, err := get(url, false)
 	if err != nil {
+		var rateErr *RateLimitError
+		if errors.As(err, &rateErr) {
+			return &u, rateErr
+		}
 		return &u, fmt.Errorf("error: reading response body: %v", err)
 	}
 
@@ -818,17 +1356,35 @@ func checkForUpdate(url string) (*Update, error) {
 		return &u, fmt.Errorf("error: parsing release: %v", err)
 	}
 
-	u.Version = r.Version
-	utility.DebugLogf("latest version: %s", u.Version)
+	// The release name may contain extra text, e.g. "1.1.12 (beta)"
+	latest, err := semver.Coerce(r.Version)
+	if err != nil {
+		return &u, fmt.Errorf("error: cannot determine the version of release %q: %v", r.Version, err)
+	}
+	u.Version = latest.String()
+	utility.DebugLogf("latest version: %s (release name %q)", u.Version, r.Version)
 
 	// Comment the next line when development is complete
 	// u.Version = "2.0.0"
 	for _, asset := range r.Assets {
+		u.releaseAssets = append(u.releaseAssets, releaseAsset(asset))
 		if value, exists := asset["name"]; exists && value.(string) == "update.exe" {
 			u.Assets = append(u.Assets, value.(string))
 		}
 		if value, exists := asset["name"]; exists && value.(string) == "nvm-noinstall.zip" {
 			u.SourceURL = asset["browser_download_url"].(string)
+			if size, ok := asset["size"].(float64); ok {
+				u.Size = int64(size)
+			}
+		}
+		if value, exists := asset["name"]; exists && value.(string) == SIGNATURE_ASSET {
+			u.SignatureURL = asset["browser_download_url"].(string)
+		}
+		if value, exists := asset["name"]; exists && value.(string) == BINARY_ASSET {
+			u.BinaryURL = asset["browser_download_url"].(string)
+		}
+		if value, exists := asset["name"]; exists && value.(string) == MANIFEST_ASSET {
+			u.ManifestURL = asset["browser_download_url"].(string)
 		}
 	}
 
@@ -836,37 +1392,36 @@ func checkForUpdate(url string) (*Update, error) {
 	utility.DebugLogf("assets: %v", u.Assets)
 
 	// Get alerts
-	utility.DebugLogf("downloading alerts from %s", ALERTS_URL)
-	body, err = get(ALERTS_URL, false)
+	if SkipAlerts {
+		utility.DebugLog("skipping alerts")
+		return &u, nil
+	}
+	utility.DebugLogf("downloading alerts from %s", AlertsURL)
+	// Alerts are informational: the update check must still succeed when the feed is unreachable
+	body, err = get(AlertsURL, false)
 	if err != nil {
-		utility.DebugLogf("alert download error: %v", err)
-		return &u, err
+		utility.DebugLogf("alert download error (continuing without alerts): %v", err)
+		return &u, nil
 	}
 
 	utility.DebugLogf("Received:\n%s", string(body))
 
-	var alerts map[string][]interface{}
-	err = json.Unmarshal(body, &alerts)
+	alerts, err := parseAlerts(body)
 	if err != nil {
-		utility.DebugLogf("alert parsing error: %v", err)
+		utility.DebugLogf("alert parsing error (continuing without alerts): %v", err)
+		return &u, nil
 	}
 
-	if value, exists := alerts["all"]; exists {
-		for _, warning := range value {
-			warn := warning.(map[string]interface{})
-			if v, exists := warn["message"]; exists {
-				u.Warnings = append(u.Warnings, v.(string))
-			}
-		}
-	}
+	now := time.Now()
+	u.Warnings = append(u.Warnings, alertMessages(filterAlerts(alerts["all"], now))...)
 
 	if value, exists := alerts[u.Version]; exists {
 		utility.DebugLogf("version warnings exist for %v\n%v", u.Version, value)
-		for _, warning := range value {
-			utility.DebugLogf("warning: %v", warning)
-			warn := warning.(map[string]interface{})
-			if v, exists := warn["message"]; exists {
-				u.VersionWarnings = append(u.VersionWarnings, v.(string))
+		filtered := filterAlerts(value, now)
+		u.VersionWarnings = append(u.VersionWarnings, alertMessages(filtered)...)
+		for _, alert := range filtered {
+			if alert.IsSecurity() {
+				u.Security = true
 			}
 		}
 	}
@@ -903,13 +1458,21 @@ func EnableVirtualTerminalProcessing() error {
 //
 //	message: 要显示的消息
 //
-// 返回值: 高亮后的字符串
+// 返回值: 高亮后的字符串(禁用颜色时原样返回)
 func highlight(message string) string {
+	if !utility.ColorEnabled() {
+		return message
+	}
 	return fmt.Sprintf("%s%s%s", yellow, message, reset)
 }
 
 // Unzip function extracts a zip file to a specified directory
 func unzip(src string, dest string) error {
+	// Make sure the archive is intact before extracting anything
+	if err := file.VerifyZip(src); err != nil {
+		return err
+	}
+
 	// Open the zip archive for reading
 	r, err := zip.OpenReader(src)
 	if err != nil {
@@ -961,37 +1524,111 @@ func unzip(src string, dest string) error {
 
 // function to compute the MD5 checksum of a file
 func computeMD5Checksum(filePath string) (string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", err
+	return computeChecksum(filePath, md5.New())
+}
+
+// computeChecksum 使用指定的哈希算法计算文件校验和(内部函数)
+// 参数:
+//
+//	filePath: 文件路径
+//	hasher: 哈希算法
+//
+// 返回值:
+//
+//	string: 十六进制校验和
+//	error: 读取文件过程中遇到的错误
+func computeChecksum(filePath string, hasher hash.Hash) (string, error) {
+	return file.Checksum(filePath, hasher)
+}
+
+// detectHashAlgo 根据校验和长度推断哈希算法(内部函数)
+// 32位十六进制为MD5，64位为SHA-256，128位为SHA-512
+// 参数:
+//
+//	checksum: 十六进制校验和
+//
+// 返回值:
+//
+//	hash.Hash: 对应的哈希算法
+//	error: 校验和不是十六进制或长度无法识别时返回的错误
+func detectHashAlgo(checksum string) (hash.Hash, error) {
+	checksum = strings.TrimSpace(checksum)
+	if _, err := hex.DecodeString(checksum); err != nil {
+		return nil, fmt.Errorf("cannot validate update file (invalid checksum %q)", checksum)
 	}
-	defer file.Close()
 
-	hasher := md5.New()
-	_, err = io.Copy(hasher, file)
-	if err != nil {
-		return "", err
+	switch len(checksum) {
+	case md5.Size * 2:
+		return md5.New(), nil
+	case sha256.Size * 2:
+		return sha256.New(), nil
+	case sha512.Size * 2:
+		return sha512.New(), nil
 	}
 
-	// Return the hex string representation of the MD5 hash
-	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+	return nil, fmt.Errorf("cannot validate update file (unrecognized checksum length %d)", len(checksum))
 }
 
-// function to read the checksum from the .checksum.txt file
-func readChecksumFromFile(checksumFile string) (string, error) {
-	file, err := os.Open(checksumFile)
+// ErrChecksumAssetMismatch 校验和文件中列出的文件名与要校验的压缩包不一致
+var ErrChecksumAssetMismatch = errors.New("checksum file does not belong to this asset")
+
+// readChecksumFromFile 读取.checksum.txt文件中的校验和(内部函数)
+// 参数:
+//
+//	checksumFile: 校验和文件路径
+//	asset: 要校验的压缩包文件名(为空时不校验文件名)
+//
+// 返回值:
+//
+//	string: 校验和
+//	error: 读取或解析失败，或文件名与asset不一致时返回的错误
+func readChecksumFromFile(checksumFile string, asset string) (string, error) {
+	data, err := os.ReadFile(checksumFile)
 	if err != nil {
 		return "", err
 	}
-	defer file.Close()
+	return parseChecksum(string(data), asset)
+}
 
+// parseChecksum 解析校验和文件内容(内部函数)
+// 支持只包含校验和的格式，以及sha256sum等工具生成的"<hash>  <filename>"格式(文件名可带"*"前缀，可包含多行)
+// 参数:
+//
+//	content: 校验和文件内容
+//	asset: 要校验的压缩包文件名(为空时不校验文件名，但多行格式仍要求只有一行)
+//
+// 返回值:
+//
+//	string: 校验和
+//	error: 内容为空，或文件名与asset不一致时返回的错误(包装ErrChecksumAssetMismatch)
+func parseChecksum(content string, asset string) (string, error) {
+	names := []string{}
 	var checksum string
-	_, err = fmt.Fscan(file, &checksum)
-	if err != nil {
-		return "", err
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 1 {
+			// 只包含校验和，没有文件名可供校验
+			return fields[0], nil
+		}
+
+		name := strings.TrimPrefix(strings.Join(fields[1:], " "), "*")
+		if asset != "" && strings.EqualFold(filepath.Base(filepath.FromSlash(name)), asset) {
+			return fields[0], nil
+		}
+		names = append(names, name)
+		checksum = fields[0]
 	}
 
-	return checksum, nil
+	if len(names) == 0 {
+		return "", errors.New("checksum file is empty")
+	}
+	if asset == "" && len(names) == 1 {
+		return checksum, nil
+	}
+	return "", fmt.Errorf("%w: expected %s, found %s", ErrChecksumAssetMismatch, asset, strings.Join(names, ", "))
 }
 
 func copyFile(src, dst string) error {
@@ -1093,6 +1730,10 @@ func zipDirectory(sourceDir, outputZip string) error {
 			if relPath == "." {
 				return nil
 			}
+			// Skip the update directory so backups do not contain earlier backups
+			if relPath == ".update" {
+				return filepath.SkipDir
+			}
 			// Add a trailing slash for directories in the zip archive.
 			relPath += "/"
 		}
@@ -1131,31 +1772,3 @@ func zipDirectory(sourceDir, outputZip string) error {
 		return nil
 	})
 }
-
-// setHidden 设置文件/目录为隐藏属性(Windows系统)
-// 参数:
-//
-//	path: 文件/目录路径
-//
-// 返回值: 操作过程中遇到的错误
-func setHidden(path string) error {
-	// Convert the path to a UTF-16 encoded string
-	lpFileName, err := syscall.UTF16PtrFromString(path)
-	if err != nil {
-		return fmt.Errorf("failed to encode path: %w", err)
-	}
-
-	// Call the Windows API function
-	ret, _, err := syscall.NewLazyDLL("kernel32.dll").
-		NewProc("SetFileAttributesW").
-		Call(
-			uintptr(unsafe.Pointer(lpFileName)),
-			uintptr(FILE_ATTRIBUTE_HIDDEN),
-		)
-
-	// Check the result
-	if ret == 0 {
-		return fmt.Errorf("failed to set hidden attribute: %w", err)
-	}
-	return nil
-}
@@ -0,0 +1,158 @@
+package upgrade
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsSelfReplacingDifferentDir(t *testing.T) {
+	selfReplacing, err := IsSelfReplacing(t.TempDir())
+	if err != nil {
+		t.Fatalf("IsSelfReplacing() error = %v", err)
+	}
+	if selfReplacing {
+		t.Error("IsSelfReplacing() = true for a directory that does not contain the running executable")
+	}
+}
+
+func TestSameExecutable(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "nvm.exe")
+	if err := os.WriteFile(exe, []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{"same path", exe, true},
+		{"different case", strings.ToUpper(exe), true},
+		{"unclean path", filepath.Join(dir, ".", "sub", "..", "nvm.exe"), true},
+		{"different directory", filepath.Join(t.TempDir(), "nvm.exe"), false},
+		{"different file", filepath.Join(dir, "author-nvm.exe"), false},
+	}
+	for _, tt := range tests {
+		if got := sameExecutable(exe, tt.path); got != tt.want {
+			t.Errorf("%s: sameExecutable(%q, %q) = %v, want %v", tt.name, exe, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseChecksum(t *testing.T) {
+	const hash = "3f786850e387550fdab836ed7e6dc881de23001b"
+	tests := []struct {
+		name    string
+		content string
+		asset   string
+		want    string
+	}{
+		{"bare hash", hash + "\r\n", "nvm-noinstall.zip", hash},
+		{"bare hash without asset", hash, "", hash},
+		{"two columns", hash + "  nvm-noinstall.zip\n", "nvm-noinstall.zip", hash},
+		{"binary marker", hash + " *nvm-noinstall.zip\r\n", "nvm-noinstall.zip", hash},
+		{"path and case", hash + "  dist/NVM-NoInstall.zip\n", "nvm-noinstall.zip", hash},
+		{"several assets", "aaaa  nvm-setup.zip\n" + hash + "  nvm-noinstall.zip\n", "nvm-noinstall.zip", hash},
+		{"two columns without asset", hash + "  nvm-noinstall.zip\n", "", hash},
+	}
+	for _, tt := range tests {
+		got, err := parseChecksum(tt.content, tt.asset)
+		if err != nil {
+			t.Errorf("%s: parseChecksum() error = %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: parseChecksum() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseChecksumRejectsOtherAssets(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		asset   string
+	}{
+		{"different asset", "aaaa  nvm-setup.zip\n", "nvm-noinstall.zip"},
+		{"several assets without asset", "aaaa  nvm-setup.zip\nbbbb  nvm-noinstall.zip\n", ""},
+	}
+	for _, tt := range tests {
+		if _, err := parseChecksum(tt.content, tt.asset); !errors.Is(err, ErrChecksumAssetMismatch) {
+			t.Errorf("%s: parseChecksum() error = %v, want ErrChecksumAssetMismatch", tt.name, err)
+		}
+	}
+
+	if _, err := parseChecksum(" \r\n\n", "nvm-noinstall.zip"); err == nil {
+		t.Error("parseChecksum() on an empty file succeeded, want error")
+	}
+}
+
+func TestEmptyResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/small" {
+			w.Write([]byte("tiny"))
+		}
+	}))
+	defer server.Close()
+	defer SetHTTPClient(HTTPClient)
+	SetHTTPClient(server.Client())
+
+	if _, err := get(server.URL+"/empty", false); !errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("get() on an empty body = %v, want ErrEmptyResponse", err)
+	}
+	if body, err := get(server.URL+"/small", false); err != nil || string(body) != "tiny" {
+		t.Errorf("get() = %q, %v, want \"tiny\"", body, err)
+	}
+
+	// sha256 of the empty string, so only the size check can reject the download
+	const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	target := filepath.Join(t.TempDir(), "assets.zip")
+	if err := Download(server.URL+"/empty", target, emptySHA256); !errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("Download() on an empty body = %v, want ErrEmptyResponse", err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Error("Download() left an empty file behind")
+	}
+
+	defer SetMinResponseSize(MinResponseSize)
+	SetMinResponseSize(16)
+	if _, err := get(server.URL+"/small", false); !errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("get() below MinResponseSize = %v, want ErrEmptyResponse", err)
+	}
+}
+
+func TestCheckForUpdateWithoutAlerts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release":
+			w.Write([]byte(`{"name":"1.2.0 (beta)","assets":[{"name":"nvm-noinstall.zip","browser_download_url":"https://example.com/nvm-noinstall.zip","size":1024}]}`))
+		case "/alerts":
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	defer SetHTTPClient(HTTPClient)
+	SetHTTPClient(server.Client())
+	defer SetAlertsURL(AlertsURL)
+	SetAlertsURL(server.URL + "/alerts")
+
+	u, err := checkForUpdate(server.URL + "/release")
+	if err != nil {
+		t.Fatalf("checkForUpdate() with a failing alerts feed = %v, want nil", err)
+	}
+	if u.Version != "1.2.0" {
+		t.Errorf("Version = %q, want 1.2.0", u.Version)
+	}
+	if u.SourceURL != "https://example.com/nvm-noinstall.zip" || u.Size != 1024 {
+		t.Errorf("SourceURL, Size = %q, %d", u.SourceURL, u.Size)
+	}
+	if len(u.Warnings) != 0 || len(u.VersionWarnings) != 0 || u.Security {
+		t.Errorf("unexpected alerts: %v %v %v", u.Warnings, u.VersionWarnings, u.Security)
+	}
+}
@@ -0,0 +1,51 @@
+package upgrade
+
+import (
+	"fmt"
+	"nvm/file"
+	"strings"
+)
+
+// ChecksumMismatchError 表示下载文件的校验和与发布的校验和不一致
+type ChecksumMismatchError struct {
+	Path     string // 被校验的文件路径
+	Expected string // 校验和文件中的值
+	Computed string // 实际计算得到的值
+}
+
+// Error 实现error接口
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("cannot validate update file (checksum mismatch): %s expected %s, computed %s", e.Path, e.Expected, e.Computed)
+}
+
+// VerifyDownload 重新校验已下载的升级包，无需重新下载
+// 校验和算法根据校验和长度自动识别，校验通过后还会检查压缩包能否正常打开
+// 本地文件可能已被重命名(如assets.zip)，因此不校验校验和文件中列出的文件名
+// 参数:
+//
+//	zipPath: 升级包路径(如assets.zip)
+//	checksumPath: 校验和文件路径(如assets.zip.checksum.txt)
+//
+// 返回值: 校验和不一致时返回*ChecksumMismatchError，其他问题返回对应错误
+func VerifyDownload(zipPath, checksumPath string) error {
+	expected, err := readChecksumFromFile(checksumPath, "")
+	if err != nil {
+		return fmt.Errorf("error reading checksum: %v", err)
+	}
+
+	hasher, err := detectHashAlgo(expected)
+	if err != nil {
+		return err
+	}
+
+	computed, err := computeChecksum(zipPath, hasher)
+	if err != nil {
+		return fmt.Errorf("Error computing checksum: %v", err)
+	}
+
+	if !strings.EqualFold(computed, strings.TrimSpace(expected)) {
+		return &ChecksumMismatchError{Path: zipPath, Expected: strings.ToLower(expected), Computed: computed}
+	}
+
+	return file.VerifyZip(zipPath)
+}
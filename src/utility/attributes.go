@@ -0,0 +1,40 @@
+package utility
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// SetHidden 设置或清除文件/目录的隐藏属性
+// 先读取现有属性再修改隐藏位，不会覆盖只读、系统等其他属性
+// 参数:
+//
+//	path: 文件/目录路径
+//	hidden: true为设置隐藏属性，false为清除隐藏属性
+//
+// 返回值: 读取或设置属性过程中遇到的错误
+func SetHidden(path string, hidden bool) error {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return fmt.Errorf("failed to encode path: %w", err)
+	}
+
+	attrs, err := windows.GetFileAttributes(name)
+	if err != nil {
+		return fmt.Errorf("failed to read attributes of %s: %w", path, err)
+	}
+
+	updated := attrs &^ windows.FILE_ATTRIBUTE_HIDDEN
+	if hidden {
+		updated |= windows.FILE_ATTRIBUTE_HIDDEN
+	}
+	if updated == attrs {
+		return nil
+	}
+
+	if err := windows.SetFileAttributes(name, updated); err != nil {
+		return fmt.Errorf("failed to set hidden attribute: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package utility
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+// attributes 读取文件属性，失败时终止测试
+func attributes(t *testing.T, path string) uint32 {
+	t.Helper()
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs, err := windows.GetFileAttributes(name)
+	if err != nil {
+		t.Fatalf("GetFileAttributes(%s) error = %v", path, err)
+	}
+	return attrs
+}
+
+func TestSetHiddenToggle(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(p, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetHidden(p, true); err != nil {
+		t.Fatalf("SetHidden(true) error = %v", err)
+	}
+	if attributes(t, p)&windows.FILE_ATTRIBUTE_HIDDEN == 0 {
+		t.Error("FILE_ATTRIBUTE_HIDDEN not set after SetHidden(true)")
+	}
+
+	if err := SetHidden(p, false); err != nil {
+		t.Fatalf("SetHidden(false) error = %v", err)
+	}
+	if attributes(t, p)&windows.FILE_ATTRIBUTE_HIDDEN != 0 {
+		t.Error("FILE_ATTRIBUTE_HIDDEN still set after SetHidden(false)")
+	}
+}
+
+func TestSetHiddenPreservesAttributes(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(p, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	name, err := windows.UTF16PtrFromString(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const preserved = windows.FILE_ATTRIBUTE_READONLY | windows.FILE_ATTRIBUTE_SYSTEM
+	if err := windows.SetFileAttributes(name, preserved); err != nil {
+		t.Fatal(err)
+	}
+	// A read-only file cannot be removed with the temporary directory
+	t.Cleanup(func() { windows.SetFileAttributes(name, windows.FILE_ATTRIBUTE_NORMAL) })
+
+	for _, hidden := range []bool{true, false} {
+		if err := SetHidden(p, hidden); err != nil {
+			t.Fatalf("SetHidden(%v) error = %v", hidden, err)
+		}
+		if attrs := attributes(t, p); attrs&preserved != preserved {
+			t.Errorf("attributes after SetHidden(%v) = %#x, want READONLY and SYSTEM kept", hidden, attrs)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package utility
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+// 颜色输出开关(-1为自动检测，0为禁用，1为启用)，通过atomic读写以支持多个goroutine并发访问
+var color int32 = -1
+
+// SetColorEnabled 设置是否在输出中使用ANSI颜色
+// 参数:
+//
+//	enabled: 是否启用颜色输出
+func SetColorEnabled(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&color, 1)
+	} else {
+		atomic.StoreInt32(&color, 0)
+	}
+}
+
+// ColorEnabled 返回是否应在输出中使用ANSI颜色
+// 未通过SetColorEnabled设置时自动检测: NO_COLOR环境变量非空(按no-color.org约定，空值不生效)、TERM为dumb或标准输出不是控制台时禁用
+// 返回值: 是否启用颜色输出
+func ColorEnabled() bool {
+	switch atomic.LoadInt32(&color) {
+	case 0:
+		return false
+	case 1:
+		return true
+	}
+
+	enabled := detectColor()
+	SetColorEnabled(enabled)
+	return enabled
+}
+
+// Colorize 使用指定的ANSI样式包装文本，禁用颜色时原样返回
+// 参数:
+//
+//	style: ANSI转义序列
+//	txt: 要显示的文本
+//
+// 返回值: 处理后的字符串
+func Colorize(style string, txt string) string {
+	if !ColorEnabled() {
+		return txt
+	}
+	return style + txt + RESET
+}
+
+// detectColor 根据环境自动检测是否支持颜色输出(内部函数)
+// 返回值: 是否支持颜色输出
+func detectColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if strings.EqualFold(os.Getenv("TERM"), "dumb") {
+		return false
+	}
+
+	// 输出被重定向到文件或管道时无法获取控制台模式
+	var mode uint32
+	if err := syscall.GetConsoleMode(syscall.Stdout, &mode); err != nil {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,26 @@
+package utility
+
+// MaxConcurrency 并行操作使用的最大goroutine数(默认为4)
+// 目前用于node.GetInstalledDetailed的架构检测，资源受限的机器可以调低，性能较好的机器可以调高。
+// 直接赋值时小于1的值同样按1处理(见Concurrency)
+var MaxConcurrency = 4
+
+// SetMaxConcurrency 设置并行操作使用的最大goroutine数
+// 参数:
+//
+//	n: 最大goroutine数(小于1时按1处理)
+func SetMaxConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	MaxConcurrency = n
+}
+
+// Concurrency 获取并行操作实际使用的最大goroutine数
+// 返回值: MaxConcurrency，小于1时返回1
+func Concurrency() int {
+	if MaxConcurrency < 1 {
+		return 1
+	}
+	return MaxConcurrency
+}
@@ -0,0 +1,30 @@
+package utility
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// FreeSpace 获取指定路径所在磁盘对当前用户可用的剩余空间
+// 参数:
+//
+//	path: 目录路径(必须存在)
+//
+// 返回值:
+//
+//	uint64: 可用字节数
+//	error: 查询过程中遇到的错误
+func FreeSpace(path string) (uint64, error) {
+	dir, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode path: %w", err)
+	}
+
+	var available, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(dir, &available, &total, &free); err != nil {
+		return 0, fmt.Errorf("failed to query free space for %s: %w", path, err)
+	}
+
+	return available, nil
+}
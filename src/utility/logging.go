@@ -6,15 +6,23 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 )
 
-// 调试日志开关
-var debug bool = false
+// 调试日志开关(1为启用，0为禁用)，通过atomic读写以支持多个goroutine并发访问
+var debug int32 = 0
 
 // 可执行文件路径
 var exe string
 
+// 最近的调试日志(无论是否启用调试输出都会记录)，供升级失败时的诊断信息读取
+var (
+	debugHistory   []string
+	debugHistoryMu sync.Mutex
+)
+
 // 项目根路径
 var path string
 
@@ -27,6 +35,8 @@ const (
 	TEXT = "\033[38;2;255;200;100m"
 	// 重置文本样式
 	RESET = "\033[0m"
+	// DEBUG_HISTORY_LINES 内存中保留的调试日志最大行数
+	DEBUG_HISTORY_LINES = 200
 )
 
 // enableANSI 在Windows上启用ANSI转义码支持
@@ -53,47 +63,83 @@ func enableANSI() {
 
 // bold 返回带粗体橙色样式的文本
 func bold(text string) string {
-	return BOLD + text + RESET
+	return Colorize(BOLD, text)
 }
 
 // text 返回带浅黄色样式的文本
 func text(txt string) string {
-	return TEXT + txt + RESET
+	return Colorize(TEXT, txt)
 }
 
 // EnableDebugLogs 启用调试日志并初始化相关配置
 func EnableDebugLogs() {
-	debug = true
 	exe, _ = os.Executable()
 	path = filepath.Join(filepath.Dir(exe), "..")
 	enableANSI()
+	atomic.StoreInt32(&debug, 1)
+}
+
+// DisableDebugLogs 禁用调试日志
+func DisableDebugLogs() {
+	atomic.StoreInt32(&debug, 0)
+}
+
+// IsDebug 返回调试日志是否已启用
+func IsDebug() bool {
+	return atomic.LoadInt32(&debug) == 1
 }
 
 // DebugLog 打印调试日志(可变参数)
 func DebugLog(args ...interface{}) {
-	if debug {
-		_, file, line, _ := runtime.Caller(1)
-		for _, arg := range args {
-			fmt.Printf(bold("[DEBUG] %v:%v")+" "+text("%v")+"\n",
-				strings.Replace(filepath.ToSlash(file), filepath.ToSlash(path), "..", 1),
-				line, arg)
-		}
+	_, file, line, _ := runtime.Caller(1)
+	for _, arg := range args {
+		debugLine(file, line, fmt.Sprint(arg))
 	}
 }
 
 // DebugLogf 打印格式化调试日志
 func DebugLogf(tpl string, args ...interface{}) {
-	if debug {
-		_, file, line, _ := runtime.Caller(1)
-		fmt.Printf(bold("[DEBUG] %v:%v")+" "+text("%v")+"\n",
-			strings.Replace(filepath.ToSlash(file), filepath.ToSlash(path), "..", 1),
-			line, fmt.Sprintf(tpl, args...))
+	_, file, line, _ := runtime.Caller(1)
+	debugLine(file, line, fmt.Sprintf(tpl, args...))
+}
+
+// RecentDebugLogs 返回最近记录的调试日志(最多DEBUG_HISTORY_LINES行，按时间先后排列)
+// 未启用调试输出时同样会记录，便于在出错后收集诊断信息
+// 返回值: 调试日志行的副本
+func RecentDebugLogs() []string {
+	debugHistoryMu.Lock()
+	defer debugHistoryMu.Unlock()
+	return append([]string{}, debugHistory...)
+}
+
+// debugLine 记录一行调试日志，启用调试时同时输出到控制台(内部函数)
+// 参数:
+//
+//	file: 调用方源文件
+//	line: 调用方行号
+//	msg: 日志内容
+func debugLine(file string, line int, msg string) {
+	// 项目根路径仅在启用调试日志时初始化，未初始化时只记录文件名
+	source := filepath.Base(file)
+	if path != "" {
+		source = strings.Replace(filepath.ToSlash(file), filepath.ToSlash(path), "..", 1)
+	}
+
+	debugHistoryMu.Lock()
+	debugHistory = append(debugHistory, fmt.Sprintf("%v:%v %v", source, line, msg))
+	if len(debugHistory) > DEBUG_HISTORY_LINES {
+		debugHistory = debugHistory[len(debugHistory)-DEBUG_HISTORY_LINES:]
+	}
+	debugHistoryMu.Unlock()
+
+	if IsDebug() {
+		fmt.Printf(bold("[DEBUG] %v:%v")+" "+text("%v")+"\n", source, line, msg)
 	}
 }
 
 // DebugFn 仅在调试模式下执行函数
 func DebugFn(fn func()) {
-	if debug {
+	if IsDebug() {
 		fn()
 	}
 }
@@ -0,0 +1,29 @@
+package utility
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRecentDebugLogsWithoutVerbose(t *testing.T) {
+	DisableDebugLogs()
+	for i := 0; i < DEBUG_HISTORY_LINES+10; i++ {
+		DebugLogf("step %d", i)
+	}
+	DebugLog("last")
+
+	lines := RecentDebugLogs()
+	if len(lines) != DEBUG_HISTORY_LINES {
+		t.Fatalf("RecentDebugLogs() returned %d lines, want %d", len(lines), DEBUG_HISTORY_LINES)
+	}
+	if !strings.HasPrefix(lines[len(lines)-1], "logging_test.go:") {
+		t.Errorf("newest line = %q, want it to start with the caller's file name", lines[len(lines)-1])
+	}
+	if !strings.HasSuffix(lines[len(lines)-1], " last") {
+		t.Errorf("newest line = %q, want it to end with \"last\"", lines[len(lines)-1])
+	}
+	if want := fmt.Sprintf("step %d", DEBUG_HISTORY_LINES+9); !strings.HasSuffix(lines[len(lines)-2], want) {
+		t.Errorf("second newest line = %q, want it to end with %q", lines[len(lines)-2], want)
+	}
+}
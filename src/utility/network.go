@@ -0,0 +1,40 @@
+package utility
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// CanReach 快速检查能否连接到指定地址，用于在耗时的下载前及时提示网络不可用
+// 先发送HEAD请求，服务器不支持HEAD时改用GET(只读取响应头)；收到任何HTTP响应即视为可连接
+// 使用标准库的默认传输层，遵循HTTP_PROXY/HTTPS_PROXY/NO_PROXY环境变量
+// 参数:
+//
+//	url: 要检查的地址
+//	timeout: 超时时间(两次请求共用)
+//
+// 返回值: 在超时时间内收到响应时返回true
+func CanReach(url string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client := &http.Client{Transport: http.DefaultTransport}
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			DebugLogf("cannot probe %s: %v", url, err)
+			return false
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			DebugLogf("%s is unreachable: %v", url, err)
+			return false
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+			return true
+		}
+	}
+	return true
+}
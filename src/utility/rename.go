@@ -47,6 +47,27 @@ func Rename(old, new string) error {
 	return nil
 }
 
+// ReplaceFile 用源文件的副本原子地替换目标文件
+// 先复制到目标目录下的临时文件，再重命名覆盖目标文件，替换过程中目标文件不会处于写了一半的状态
+// 参数:
+//
+//	src: 源文件路径(保留不变)
+//	dst: 要替换的目标文件路径(不存在时创建)
+//
+// 返回值: 复制或替换过程中遇到的错误(出错时目标文件保持原样)
+func ReplaceFile(src, dst string) error {
+	tmp := dst + ".tmp"
+	if err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", dst, err)
+	}
+	return nil
+}
+
 // copyFile 复制单个文件从源路径(old)到目标路径(new)
 func copyFile(old, new string) error {
 	// 打开源文件
@@ -0,0 +1,94 @@
+package utility
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Scope 表示nvm的安装范围
+type Scope int
+
+const (
+	Unknown    Scope = iota // 无法确定
+	PerUser                 // 仅为当前用户安装(如位于LOCALAPPDATA中)，升级和计划任务无需管理员权限
+	PerMachine              // 为所有用户安装(如位于Program Files中)，升级需要管理员权限
+)
+
+// String 返回安装范围的文字描述
+func (s Scope) String() string {
+	switch s {
+	case PerUser:
+		return "user"
+	case PerMachine:
+		return "machine"
+	default:
+		return "unknown"
+	}
+}
+
+// InstallScope 根据安装路径和写入权限判断nvm的安装范围
+// 位于用户目录(USERPROFILE/LOCALAPPDATA/APPDATA)中时为PerUser，位于Program Files、ProgramData或系统目录中时为PerMachine，
+// 其他位置当前用户可写入时为PerUser，否则为PerMachine
+// 参数:
+//
+//	installDir: nvm安装目录
+//
+// 返回值:
+//
+//	Scope: 安装范围
+//	error: 路径无效或目录不存在时返回Unknown和对应的错误
+func InstallScope(installDir string) (Scope, error) {
+	dir, err := filepath.Abs(installDir)
+	if err != nil {
+		return Unknown, fmt.Errorf("cannot resolve install directory %s: %w", installDir, err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return Unknown, fmt.Errorf("cannot determine install scope: %w", err)
+	}
+	if !info.IsDir() {
+		return Unknown, fmt.Errorf("cannot determine install scope: %s is not a directory", dir)
+	}
+
+	for _, env := range []string{"LOCALAPPDATA", "APPDATA", "USERPROFILE"} {
+		if within(dir, os.Getenv(env)) {
+			return PerUser, nil
+		}
+	}
+	for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)", "ProgramW6432", "ProgramData", "SystemRoot"} {
+		if within(dir, os.Getenv(env)) {
+			return PerMachine, nil
+		}
+	}
+
+	// 无法通过路径判断时，根据当前用户能否写入安装目录判断
+	probe, err := os.CreateTemp(dir, ".nvm-scope-test-*")
+	if err != nil {
+		DebugLogf("%s is not writable, assuming a machine-wide install: %v", dir, err)
+		return PerMachine, nil
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return PerUser, nil
+}
+
+// within 检查路径是否位于指定目录中(内部函数)
+// Windows上filepath.Rel不区分大小写
+// 参数:
+//
+//	path: 要检查的绝对路径
+//	root: 目录路径(为空时返回false)
+//
+// 返回值: path等于root或位于root之下时返回true
+func within(path, root string) bool {
+	if strings.TrimSpace(root) == "" {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Clean(root), path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator)) && !filepath.IsAbs(rel)
+}
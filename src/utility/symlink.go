@@ -0,0 +1,43 @@
+package utility
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// CreateSymlink 创建指向target的符号链接link，尽量不需要管理员权限
+// os.Symlink在开发者模式(Windows 10及以上)下无需管理员权限即可创建符号链接；
+// 因权限不足失败且target为目录时，改为创建目录联接(junction)，普通用户即可创建
+// 参数:
+//
+//	link: 要创建的链接路径
+//	target: 链接指向的目标路径
+//
+// 返回值: 符号链接和目录联接都无法创建时返回的错误
+func CreateSymlink(link, target string) error {
+	err := os.Symlink(target, link)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, windows.ERROR_PRIVILEGE_NOT_HELD) {
+		return fmt.Errorf("failed to create symlink %s -> %s: %w", link, target, err)
+	}
+
+	info, serr := os.Stat(target)
+	if serr != nil || !info.IsDir() {
+		return fmt.Errorf("failed to create symlink %s -> %s: administrator rights or Developer Mode are required: %w", link, target, err)
+	}
+
+	// 目录联接不需要SeCreateSymbolicLinkPrivilege权限
+	DebugLogf("symlink creation requires elevation, creating a junction instead: %v", err)
+	out, jerr := exec.Command("cmd", "/c", "mklink", "/J", link, target).CombinedOutput()
+	if jerr != nil {
+		return fmt.Errorf("failed to create symlink or junction %s -> %s: %v (%s)", link, target, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package utility
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// WindowsVersion 获取当前Windows系统的真实版本号
+// 使用RtlGetVersion，不受应用程序兼容性清单的影响(GetVersionEx在未声明兼容性的程序中会返回6.2)，
+// 可用于按系统版本启用功能(如Windows 10开发者模式下无需管理员权限创建符号链接)
+// 返回值:
+//
+//	major: 主版本号(Windows 10/11为10)
+//	minor: 次版本号
+//	build: 内部版本号(Windows 11从22000开始)
+//	err: 无法获取版本信息时返回的错误
+func WindowsVersion() (major, minor, build uint32, err error) {
+	info := windows.RtlGetVersion()
+	if info == nil || info.MajorVersion == 0 {
+		return 0, 0, 0, errors.New("unable to determine the Windows version: RtlGetVersion is not supported on this platform")
+	}
+	return info.MajorVersion, info.MinorVersion, info.BuildNumber, nil
+}
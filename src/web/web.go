@@ -7,6 +7,7 @@
 package web
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
@@ -273,31 +274,7 @@ func GetNodeJS(root string, v string, a string, append bool) bool {
 	utility.DebugLogf("running GetNodeJS with root: %v, v%v, arch: %v, append: %v", root, v, a, append)
 	a = arch.Validate(a)
 
-	vpre := ""
-	vers := strings.Fields(strings.Replace(v, ".", " ", -1))
-	main, _ := strconv.ParseInt(vers[0], 0, 0)
-
-	if a == "32" {
-		if main > 0 {
-			vpre = "win-x86/"
-		} else {
-			vpre = ""
-		}
-	} else if a == "64" {
-		if main > 0 {
-			vpre = "win-x64/"
-		} else {
-			vpre = "x64/"
-		}
-	} else if a == "arm64" {
-		if main > 0 {
-			vpre = "win-arm64/"
-		} else {
-			vpre = "arm64/"
-		}
-	}
-
-	url := getNodeUrl(v, vpre, a, append)
+	url := getNodeUrl(v, exePrefix(v, a), a, append)
 
 	utility.DebugLogf("download url: %v", url)
 
@@ -419,17 +396,38 @@ func GetNpm(root string, v string) bool {
 //	string: 文件内容
 //	error: 获取过程中遇到的错误
 func GetRemoteTextFile(url string) (string, error) {
-	response, httperr := client.Get(url)
+	return GetRemoteTextFileContext(context.Background(), url)
+}
+
+// GetRemoteTextFileContext 获取远程文本文件内容(支持取消和超时)
+// 参数:
+//
+//	ctx: 控制请求生命周期的上下文
+//	url: 文件URL地址
+//
+// 返回值:
+//
+//	string: 文件内容
+//	error: 获取过程中遇到的错误(上下文取消或超时时包装ctx.Err())
+func GetRemoteTextFileContext(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("Could not retrieve %v: %v", url, err)
+	}
+
+	response, httperr := client.Do(req)
 	if httperr != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("Could not retrieve %v: %w", url, ctx.Err())
+		}
 		return "", fmt.Errorf("Could not retrieve %v: %v", url, httperr)
 	}
+	defer response.Body.Close()
 
 	if response.StatusCode != 200 {
 		return "", fmt.Errorf("Error retrieving \"%s\": HTTP Status %v\n", url, response.StatusCode)
 	}
 
-	defer response.Body.Close()
-
 	contents, readerr := ioutil.ReadAll(response.Body)
 	if readerr != nil {
 		return "", fmt.Errorf("error reading HTTP request body: %v", readerr)
@@ -488,6 +486,61 @@ func IsNodeArm64bitAvailable(v string) bool {
 	return true
 }
 
+// exePrefix 获取node.exe下载路径中的架构目录(内部函数)
+// 参数:
+//
+//	v: 版本号
+//	a: 架构(32/64/arm64)
+//
+// 返回值: 架构目录前缀(如"win-x64/")，0.x的32位版本为空字符串
+func exePrefix(v string, a string) string {
+	vers := strings.Fields(strings.Replace(v, ".", " ", -1))
+	main := int64(0)
+	if len(vers) > 0 {
+		main, _ = strconv.ParseInt(vers[0], 0, 0)
+	}
+
+	if a == "32" {
+		if main > 0 {
+			return "win-x86/"
+		}
+		return ""
+	} else if a == "64" {
+		if main > 0 {
+			return "win-x64/"
+		}
+		return "x64/"
+	} else if a == "arm64" {
+		if main > 0 {
+			return "win-arm64/"
+		}
+		return "arm64/"
+	}
+	return ""
+}
+
+// GetDownloadURL 获取指定版本和架构的Node.js下载地址(不检查地址是否可用)
+// 16.9.0及以上版本使用包含npm和corepack的zip压缩包，较早的版本只下载node.exe
+// 参数:
+//
+//	v: 版本号(可带"v"前缀)
+//	a: 架构(32/64/arm64)
+//
+// 返回值: 下载URL
+func GetDownloadURL(v string, a string) string {
+	v = strings.TrimPrefix(v, "v")
+	a = arch.Validate(a)
+
+	version, err := semver.Make(v)
+	corepack, _ := semver.Make("16.9.0")
+	if err != nil || version.LT(corepack) {
+		return GetFullNodeUrl("v" + v + "/" + exePrefix(v, a) + "node.exe")
+	}
+
+	name := map[string]string{"32": "x86", "64": "x64", "arm64": "arm64"}[a]
+	return GetFullNodeUrl("v" + v + "/node-v" + v + "-win-" + name + ".zip")
+}
+
 // getNodeUrl 获取Node.js下载URL(内部函数)
 // 参数:
 //